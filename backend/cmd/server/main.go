@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -11,8 +12,13 @@ import (
 	privateapi "github.com/TeamA166/WonderTrip/internal/api/private"
 	"github.com/TeamA166/WonderTrip/internal/api/public"
 	"github.com/TeamA166/WonderTrip/internal/config"
+	"github.com/TeamA166/WonderTrip/internal/core"
 	"github.com/TeamA166/WonderTrip/internal/database"
+	"github.com/TeamA166/WonderTrip/internal/jobs"
+	"github.com/TeamA166/WonderTrip/internal/ratelimit"
+	"github.com/TeamA166/WonderTrip/internal/realtime"
 	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
@@ -38,19 +44,74 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	postRepo := repository.NewPostRepository(db)
 	resetRepo := repository.NewPResetRepository(db)
+	moderationRepo := repository.NewModerationRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	verificationRepo := repository.NewEmailVerificationRepository(db)
+	emailChangeRepo := repository.NewEmailChangeRepository(db)
+	attemptRepo := repository.NewLoginAttemptRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	oidcRepo := repository.NewOIDCRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	followRepo := repository.NewFollowRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	entityRepo := repository.NewEntityRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+
+	signingKeys, err := tokens.KeysFromConfig(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	signer, err := tokens.NewSigner(signingKeys)
+	if err != nil {
+		log.Fatalf("Failed to initialize token signer: %v", err)
+	}
+
+	broker, err := realtime.New(cfg.Realtime, db, database.BuildDSN(&cfg))
+	if err != nil {
+		log.Fatalf("Failed to initialize realtime broker: %v", err)
+	}
 
 	//Handlers
+	notifier := privateapi.NewNotifier(notificationRepo, broker)
 	tokenExpiry := time.Duration(cfg.Auth.AccessTokenMinutes) * time.Minute
-	authHandler, err := public.NewAuthHandler(userRepo, cfg.Auth.JWTSecret, tokenExpiry, cfg.Auth.PasswordHashingCost)
-	postHandler := privateapi.NewPostHandler(postRepo)
-	resetHandler := public.NewPasswordResetHandler(resetRepo, userRepo)
-	profileHandler := privateapi.NewProfileHandler(userRepo)
-	authMiddleware := middleware.NewAuthMiddleware(cfg.Auth.JWTSecret)
+	refreshExpiry := time.Duration(cfg.Auth.RefreshTokenDays) * 24 * time.Hour
+	authHandler, err := public.NewAuthHandler(userRepo, sessionRepo, verificationRepo, mfaRepo, accountRepo, attemptRepo, signer, tokenExpiry, refreshExpiry, cfg.Auth.PasswordHashingCost)
+	postHandler := privateapi.NewPostHandler(postRepo, moderationRepo, entityRepo, notifier, broker, signer)
+	resetHandler := public.NewPasswordResetHandler(resetRepo, userRepo, sessionRepo, attemptRepo, signer)
+	profileHandler := privateapi.NewProfileHandler(userRepo, sessionRepo, followRepo)
+	sessionHandler := privateapi.NewSessionHandler(sessionRepo)
+	verificationHandler := public.NewEmailVerificationHandler(verificationRepo, userRepo, attemptRepo)
+	moderationHandler := privateapi.NewModerationHandler(moderationRepo, postRepo, notifier, broker)
+	roleHandler := privateapi.NewRoleHandler(roleRepo)
+	emailChangeHandler := privateapi.NewEmailChangeHandler(emailChangeRepo, userRepo, attemptRepo)
+	emailRevertHandler := public.NewEmailChangeRevertHandler(emailChangeRepo, userRepo, sessionRepo)
+	mfaHandler := privateapi.NewMFAHandler(mfaRepo, userRepo, attemptRepo)
+	oidcHandler := public.NewOIDCHandler(authHandler, oidcRepo, cfg.OIDC.Providers)
+	jwksHandler := public.NewJWKSHandler(signer)
+	photoLinkHandler := public.NewPhotoLinkHandler(signer)
+	followHandler := privateapi.NewFollowHandler(followRepo, notifier)
+	commentHandler := privateapi.NewCommentHandler(commentRepo, postRepo, entityRepo, notifier, broker)
+	notificationHandler := privateapi.NewNotificationHandler(notificationRepo)
+	eventsHandler := privateapi.NewEventsHandler(broker, postRepo, sessionRepo)
+	accountHandler := privateapi.NewAccountHandler(accountRepo, userRepo, postRepo, sessionRepo, attemptRepo, time.Duration(cfg.Account.DeletionGraceDays)*24*time.Hour)
+	authMiddleware := middleware.NewAuthMiddleware(signer, sessionRepo)
+	moderatorOnly := middleware.RequireRole(core.RoleModerator, core.RoleAdmin)
+	adminOnly := middleware.RequireRole(core.RoleAdmin)
+	verifiedOnly := middleware.RequireVerifiedEmail(cfg.Auth.RequireVerifiedEmail, userRepo)
 
 	if err != nil {
 		log.Fatalf("Failed to initialize auth handler: %v", err)
 	}
 
+	limiter, err := ratelimit.New(cfg.RateLimit, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limiter: %v", err)
+	}
+	rateLimit := func(name string) fiber.Handler {
+		return middleware.RateLimit(limiter, ratelimit.PolicyFor(cfg.RateLimit, name))
+	}
+
 	app := fiber.New()
 
 	// app.Use(cors.New(cors.Config{
@@ -64,32 +125,49 @@ func main() {
 
 	app.Use(logger.New())
 
+	app.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	v1 := app.Group("/api/v1") //api to auth
 	{
 		v1.Get("/title", loadScreenHandler.GetTitle)
+		v1.Get("/photos/:filename", rateLimit("public"), photoLinkHandler.GetPhoto)
 
-		auth := v1.Group("/auth")
-		auth.Post("/register", authHandler.Register)
-		auth.Post("/login", authHandler.Login)
-		auth.Post("/forgot-password", resetHandler.RequestReset)
-		auth.Post("/verify-code", resetHandler.VerifyOTP)
-		auth.Post("/reset-password", resetHandler.ResetPassword)
+		auth := v1.Group("/auth", rateLimit("public"))
+		auth.Post("/register", rateLimit("auth"), authHandler.Register)
+		auth.Post("/login", rateLimit("auth"), authHandler.Login)
+		auth.Post("/login/mfa", rateLimit("auth"), authHandler.LoginMFA)
+		auth.Get("/oidc/:provider/login", rateLimit("auth"), oidcHandler.Login)
+		auth.Get("/oidc/:provider/callback", rateLimit("auth"), oidcHandler.Callback)
+		auth.Post("/refresh", authHandler.Refresh)
+		auth.Post("/verify-email", rateLimit("auth"), verificationHandler.VerifyEmail)
+		auth.Post("/resend-verification", rateLimit("password_reset"), verificationHandler.ResendVerification)
+		auth.Post("/forgot-password", rateLimit("password_reset"), resetHandler.RequestReset)
+		auth.Post("/verify-code", rateLimit("auth"), resetHandler.VerifyOTP)
+		auth.Post("/reset-password", rateLimit("auth"), resetHandler.ResetPassword)
+		auth.Get("/email-change/revert", emailRevertHandler.ShowRevertEmailChange)
+		auth.Post("/email-change/revert", rateLimit("auth"), emailRevertHandler.RevertEmailChange)
 		auth.Post("/logout", authHandler.Logout)
 		auth.Get("/me", authMiddleware, authHandler.GetMe)
 
 	}
 
-	protected := v1.Group("/protected", authMiddleware)
+	protected := v1.Group("/protected", authMiddleware, rateLimit("protected"))
 	{
-		protected.Post("/posts", postHandler.Publish)
+		protected.Post("/posts", verifiedOnly, rateLimit("publish"), postHandler.Publish)
+		protected.Post("/posts/import", verifiedOnly, rateLimit("publish"), postHandler.ImportPosts)
 		protected.Get("/posts", postHandler.GetVerifiedPosts)
 		protected.Get("/feed", postHandler.GetFeed)
+		protected.Get("/tags/:tag/posts", postHandler.GetPostsByTag)
 		//
-		protected.Get("/posts/unverified", postHandler.GetUnverifiedPosts)
+		protected.Get("/posts/unverified", moderatorOnly, postHandler.GetUnverifiedPosts)
+		protected.Get("/posts/nearby", postHandler.GetNearbyPosts)
+		protected.Get("/posts/map", postHandler.GetMapPosts)
 		protected.Get("/posts/photo/:filename", postHandler.GetPostPhoto)
 		protected.Get("/posts/me", postHandler.GetMyPosts)
+		protected.Get("/posts/me/export", postHandler.ExportMyPosts)
 		protected.Delete("/posts/:id", postHandler.DeletePost)
 		protected.Put("/posts/:id", postHandler.UpdatePost)
+		protected.Get("/posts/:id/moderation-history", moderationHandler.GetPostHistory)
 
 		protected.Get("/profile-photo", profileHandler.GetProfilePhoto)
 		protected.Get("/profile", profileHandler.GetProfile)
@@ -97,23 +175,74 @@ func main() {
 
 		protected.Post("/profile-photo", profileHandler.UploadProfilePhoto)
 		protected.Put("/password", profileHandler.ChangePassword)
+		protected.Post("/email-change", rateLimit("password_reset"), emailChangeHandler.RequestEmailChange)
+		protected.Post("/email-change/confirm", emailChangeHandler.ConfirmEmailChange)
+
+		protected.Get("/users/:id", profileHandler.GetPublicProfile)
+		protected.Post("/users/:id/follow", rateLimit("follow"), followHandler.Follow)
+		protected.Delete("/users/:id/follow", rateLimit("follow"), followHandler.Unfollow)
+		protected.Get("/users/:id/followers", followHandler.GetFollowers)
+		protected.Get("/users/:id/following", followHandler.GetFollowing)
+
+		protected.Get("/events", eventsHandler.Stream)
+		protected.Get("/notifications", notificationHandler.GetNotifications)
+		protected.Get("/notifications/unread-count", notificationHandler.GetUnreadCount)
+		protected.Post("/notifications/read-all", notificationHandler.MarkAllRead)
+		protected.Get("/notifications/preferences", notificationHandler.GetPreferences)
+		protected.Put("/notifications/preferences", notificationHandler.UpdatePreferences)
+		protected.Post("/notifications/:id/read", notificationHandler.MarkRead)
+
+		protected.Delete("/account", accountHandler.DeleteAccount)
+		protected.Post("/account/delete-code", rateLimit("password_reset"), accountHandler.RequestDeletionCode)
+		protected.Get("/account/export", accountHandler.ExportAccount)
+
+		protected.Get("/mfa", mfaHandler.GetStatus)
+		protected.Post("/mfa/enroll", mfaHandler.Enroll)
+		protected.Post("/mfa/confirm", mfaHandler.Confirm)
+		protected.Post("/mfa/disable", mfaHandler.Disable)
+
+		protected.Get("/sessions", sessionHandler.ListSessions)
+		protected.Delete("/sessions", sessionHandler.RevokeOtherSessions)
+		protected.Delete("/sessions/:id", sessionHandler.RevokeSession)
 
-		protected.Post("/posts/:id/comments", postHandler.AddComment)
-		protected.Get("/posts/:id/comments", postHandler.GetComments)
+		protected.Post("/posts/:id/comments", rateLimit("comment"), commentHandler.AddComment)
+		protected.Get("/posts/:id/comments", commentHandler.GetComments)
+		protected.Get("/comments/:id", commentHandler.GetComment)
+		protected.Get("/comments/:id/replies", commentHandler.GetReplies)
+		protected.Put("/comments/:id", rateLimit("comment"), commentHandler.UpdateComment)
+		protected.Delete("/comments/:id", commentHandler.DeleteComment)
+		protected.Post("/comments/:id/like", rateLimit("like"), commentHandler.ToggleLike)
+		protected.Get("/comments/:id/like", commentHandler.CheckLikeStatus)
 		protected.Get("/users/photos/:filename", profileHandler.GetUserProfilePhoto)
 
 		protected.Post("/posts/:id/favorite", postHandler.ToggleFavorite)
 		protected.Get("/posts/:id/favorite", postHandler.CheckFavoriteStatus)
 		protected.Get("/favorites", postHandler.GetUserFavorites)
+		protected.Get("/favorites/export", postHandler.ExportFavorites)
 
 		protected.Get("/users/:id/posts", postHandler.GetUserPosts)
-		protected.Post("/posts/:id/like", postHandler.ToggleLike)
+		protected.Post("/posts/:id/like", rateLimit("like"), postHandler.ToggleLike)
 		protected.Get("/posts/:id/like", postHandler.CheckLikeStatus)
 
 		protected.Get("/posts/:id/like-count", postHandler.GetPostLikeCount)
 
 	}
 
+	admin := v1.Group("/admin", authMiddleware, moderatorOnly)
+	{
+		admin.Post("/posts/moderate", moderationHandler.BulkModerate)
+		admin.Post("/posts/:id/approve", moderationHandler.ApprovePost)
+		admin.Post("/posts/:id/reject", moderationHandler.RejectPost)
+
+		admin.Get("/users", adminOnly, roleHandler.GetUsersByRole)
+		admin.Put("/users/:id/role", adminOnly, roleHandler.UpdateUserRole)
+		admin.Get("/users/:id/role-history", adminOnly, roleHandler.GetRoleHistory)
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go jobs.NewAccountPurger(accountRepo, time.Hour).Run(jobsCtx)
+
 	serverErr := make(chan error, 1)
 	go func() {
 		log.Printf("Starting server on port %s", cfg.Port)
@@ -134,6 +263,11 @@ func main() {
 	}
 
 	log.Println("Shutting down server gracefully...")
+	stopJobs()
+	// Closing the broker ends open event streams, which would otherwise hold the shutdown.
+	if err := broker.Close(); err != nil {
+		log.Printf("Realtime broker shutdown error: %v", err)
+	}
 	if err := app.Shutdown(); err != nil {
 		log.Fatalf("Fiber server shutdown error: %v", err)
 	}
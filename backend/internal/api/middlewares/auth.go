@@ -1,13 +1,25 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func NewAuthMiddleware(secret string) fiber.Handler {
+// sessionCheckTTL is how long a session found active is trusted without asking the
+// database again; revoking a session takes at most this long to lock its tokens out.
+const sessionCheckTTL = 10 * time.Second
+
+func NewAuthMiddleware(signer *tokens.Signer, sessions repository.SessionRepository) fiber.Handler {
+	cache := newSessionCache(sessionCheckTTL)
+
 	return func(c *fiber.Ctx) error {
 		var tokenString string
 
@@ -26,26 +38,84 @@ func NewAuthMiddleware(secret string) fiber.Handler {
 			})
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.ErrUnauthorized
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		// Any non-retired key is accepted, so keys can be rotated without logging anyone out.
+		// Other token types from the same signer (e.g. "mfa_pending") must not open protected routes.
+		claims, err := signer.Parse(tokenString, tokens.TypeAccess)
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Unauthorized: Invalid or expired token",
 			})
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Locals("userID", claims["sub"])  // Matches "sub": user.ID
-			c.Locals("email", claims["email"]) // Matches "email": user.Email
-			c.Locals("role", claims["role"])   // Matches "role": user.Role
+		// A valid signature is not enough: logout, session revocation and password
+		// changes revoke the session, and its access tokens must stop working with it.
+		sessionID, err := uuid.Parse(fmt.Sprint(claims["sid"]))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized: Invalid or expired token",
+			})
+		}
+		if !cache.fresh(sessionID) {
+			ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+			active, err := sessions.IsSessionActive(ctx, sessionID)
+			cancel()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Could not check session",
+				})
+			}
+			if !active {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Unauthorized: Session has been revoked",
+				})
+			}
+			cache.store(sessionID)
 		}
 
+		c.Locals("userID", claims["sub"])  // Matches "sub": user.ID
+		c.Locals("email", claims["email"]) // Matches "email": user.Email
+		c.Locals("role", claims["role"])   // Matches "role": user.Role
+		c.Locals("sessionID", claims["sid"])
+		c.Locals("expiresAt", claims["exp"])
+
 		return c.Next()
 	}
 }
+
+// sessionCache remembers sessions recently found active. Revoked ones are never cached.
+type sessionCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	checked   map[uuid.UUID]time.Time
+	lastSweep time.Time
+}
+
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{ttl: ttl, checked: make(map[uuid.UUID]time.Time), lastSweep: time.Now()}
+}
+
+func (s *sessionCache) fresh(sessionID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkedAt, ok := s.checked[sessionID]
+	return ok && time.Since(checkedAt) < s.ttl
+}
+
+func (s *sessionCache) store(sessionID uuid.UUID) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checked[sessionID] = now
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for id, checkedAt := range s.checked {
+		if now.Sub(checkedAt) >= s.ttl {
+			delete(s.checked, id)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/TeamA166/WonderTrip/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimit throttles requests with the given policy. Behind NewAuthMiddleware the bucket
+// belongs to the user, otherwise to the client IP. Limiter errors let the request through.
+func RateLimit(limiter ratelimit.Limiter, policy ratelimit.Policy) fiber.Handler {
+	if policy.Unlimited() {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := policy.Name + ":ip:" + c.IP()
+		if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+			key = policy.Name + ":user:" + userID
+		}
+
+		result, err := limiter.Allow(c.UserContext(), key, policy)
+		if err != nil {
+			fmt.Printf("rate limit %s: %v\n", policy.Name, err)
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(policy.Burst))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":       "Too many requests. Please slow down.",
+				"retry_after": seconds,
+			})
+		}
+		return c.Next()
+	}
+}
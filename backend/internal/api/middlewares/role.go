@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole must run after NewAuthMiddleware; it relies on the role claim it stores.
+// Changing a user's role revokes their sessions, so a token never outlives the role it carries.
+func RequireRole(roles ...string) fiber.Handler {
+	allowed := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		allowed[r] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if !allowed[role] {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Forbidden: insufficient role",
+			})
+		}
+		return c.Next()
+	}
+}
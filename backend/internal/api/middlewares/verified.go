@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequireVerifiedEmail blocks users who have not confirmed their email address.
+// It is a no-op unless auth.require_verified_email is enabled, and must run after NewAuthMiddleware.
+func RequireVerifiedEmail(enabled bool, repo repository.UserRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !enabled {
+			return c.Next()
+		}
+
+		userID, err := uuid.Parse(fmt.Sprint(c.Locals("userID")))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+		defer cancel()
+
+		verified, err := repo.IsEmailVerified(ctx, userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not check email verification"})
+		}
+		if !verified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Please verify your email address before publishing",
+			})
+		}
+
+		return c.Next()
+	}
+}
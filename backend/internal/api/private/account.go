@@ -0,0 +1,287 @@
+package private
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/api/throttle"
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const accountDeletionCodeTTL = 15 * time.Minute
+
+type AccountHandler struct {
+	repo        repository.AccountRepository
+	userRepo    repository.UserRepository
+	postRepo    repository.PostRepository
+	sessions    repository.SessionRepository
+	codeGuard   throttle.Guard
+	gracePeriod time.Duration
+}
+
+func NewAccountHandler(repo repository.AccountRepository, userRepo repository.UserRepository, postRepo repository.PostRepository, sessions repository.SessionRepository, attempts repository.LoginAttemptRepository, gracePeriod time.Duration) *AccountHandler {
+	if gracePeriod <= 0 {
+		gracePeriod = 30 * 24 * time.Hour
+	}
+	return &AccountHandler{
+		repo:        repo,
+		userRepo:    userRepo,
+		postRepo:    postRepo,
+		sessions:    sessions,
+		codeGuard:   throttle.NewGuard(attempts, "delete_account"),
+		gracePeriod: gracePeriod,
+	}
+}
+
+// POST /api/v1/protected/account/delete-code
+// Mails a code that confirms the deletion instead of the password. Only offered to users with a
+// linked login provider, since an account created that way has a random password nobody knows.
+func (h *AccountHandler) RequestDeletionCode(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	linked, err := h.repo.HasLinkedIdentity(ctx, userID)
+	if err != nil {
+		fmt.Printf("check linked identity: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	if !linked {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Please confirm with your password"})
+	}
+
+	user, err := h.userRepo.GetById(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	otp, err := utils.GenerateOTP()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	otpHash, err := utils.HashCode(otp)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	if err := h.repo.SaveDeletionCode(ctx, core.AccountDeletionCode{
+		UserID:    userID,
+		CodeHash:  otpHash,
+		ExpiresAt: time.Now().Add(accountDeletionCodeTTL),
+	}); err != nil {
+		fmt.Printf("save deletion code: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	go func(targetEmail, code string) {
+		subject := "WonderTrip - Confirm account deletion"
+		body := fmt.Sprintf("Hi,\n\nUse this code to confirm that you want to delete your WonderTrip account: %s\n\nThis code will be expired after 15 minutes. If you did not request this, you can ignore this email.", code)
+
+		if err := utils.SendEmail(targetEmail, subject, body); err != nil {
+			fmt.Printf("Email error for %s: %v\n", targetEmail, err)
+		}
+	}(user.Email, otp)
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"message": "We sent a confirmation code to your email address"})
+}
+
+// DELETE /api/v1/protected/account
+// Schedules the account for deletion and signs it out everywhere. Logging in again
+// before the grace period ends cancels the deletion. Confirmed with the password, or
+// with a code from /account/delete-code.
+func (h *AccountHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req core.DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByIdForPassword(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	if code := strings.TrimSpace(req.Code); code != "" {
+		account := userID.String()
+		if wait := h.codeGuard.LockedFor(ctx, account, c.IP()); wait > 0 {
+			return throttle.TooManyAttempts(c, wait)
+		}
+		stored, err := h.repo.GetDeletionCode(ctx, userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("get deletion code: %v\n", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+		}
+		if err != nil || time.Now().After(stored.ExpiresAt) || !utils.VerifyHash(stored.CodeHash, code) {
+			if wait := h.codeGuard.Fail(ctx, account, c.IP()); wait > 0 {
+				return throttle.TooManyAttempts(c, wait)
+			}
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired code"})
+		}
+		h.codeGuard.Succeed(ctx, account)
+		if err := h.repo.DeleteDeletionCode(ctx, userID); err != nil {
+			fmt.Printf("delete deletion code: %v\n", err)
+		}
+	} else if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Incorrect password"})
+	}
+
+	deleteAt := time.Now().Add(h.gracePeriod)
+	if err := h.repo.ScheduleDeletion(ctx, userID, deleteAt); err != nil {
+		fmt.Printf("schedule deletion: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete account"})
+	}
+
+	if _, err := h.sessions.RevokeOtherSessions(ctx, userID, uuid.Nil, "account_deleted"); err != nil {
+		fmt.Printf("revoke sessions after account deletion: %v\n", err)
+	}
+
+	if user.Email != "" {
+		go func(targetEmail string, at time.Time) {
+			subject := "WonderTrip - Your account will be deleted"
+			body := fmt.Sprintf("Hi,\n\nYour WonderTrip account and everything you shared will be permanently deleted on %s.\n\nChanged your mind? Just log in before then and the deletion will be cancelled.", at.Format("2 January 2006"))
+
+			if err := utils.SendEmail(targetEmail, subject, body); err != nil {
+				fmt.Printf("Email error for %s: %v\n", targetEmail, err)
+			}
+		}(user.Email, deleteAt)
+	}
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"message":                "Your account will be deleted. Log in again before the date below to cancel.",
+		"deletion_scheduled_for": deleteAt,
+	})
+}
+
+// GET /api/v1/protected/account/export
+// Streams a ZIP with everything stored about the user, including their original photos.
+func (h *AccountHandler) ExportAccount(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
+
+	profile, err := h.repo.GetExportProfile(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export account"})
+	}
+	posts, err := h.postRepo.GetPostsByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export account"})
+	}
+	comments, err := h.repo.GetUserComments(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export account"})
+	}
+	likes, err := h.repo.GetUserLikes(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export account"})
+	}
+	favorites, err := h.repo.GetUserFavorites(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export account"})
+	}
+
+	documents := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"posts.json", posts},
+		{"comments.json", comments},
+		{"likes.json", likes},
+		{"favorites.json", favorites},
+	}
+
+	photos := map[string]string{}
+	for _, p := range posts {
+		if utils.IsUserUpload(p.PhotoPath) {
+			photos["photos/posts/"+filepath.Base(p.PhotoPath)] = p.PhotoPath
+		}
+	}
+	if utils.IsUserUpload(profile.ProfilePath) {
+		photos["photos/profile/"+filepath.Base(profile.ProfilePath)] = profile.ProfilePath
+	}
+
+	filename := fmt.Sprintf("wondertrip-export-%s.zip", time.Now().Format("20060102"))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		zw := zip.NewWriter(w)
+
+		for _, doc := range documents {
+			f, err := zw.Create(doc.name)
+			if err != nil {
+				fmt.Printf("export account: %v\n", err)
+				return
+			}
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(doc.data); err != nil {
+				fmt.Printf("export account: %v\n", err)
+				return
+			}
+		}
+
+		for name, path := range photos {
+			if err := addFileToZip(zw, name, path); err != nil {
+				fmt.Printf("export account photo %s: %v\n", path, err)
+			}
+		}
+
+		if err := zw.Close(); err != nil {
+			fmt.Printf("export account: %v\n", err)
+			return
+		}
+		if err := w.Flush(); err != nil {
+			fmt.Printf("export account flush: %v\n", err)
+		}
+	})
+
+	return nil
+}
+
+// addFileToZip stores already-compressed images without recompressing them.
+func addFileToZip(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,405 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/realtime"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	maxCommentLength   = 2000
+	maxCommentPageSize = 50
+)
+
+type CommentHandler struct {
+	repo     repository.CommentRepository
+	posts    repository.PostRepository
+	entities repository.EntityRepository
+	notifier *Notifier
+	events   realtime.Broker
+}
+
+func NewCommentHandler(repo repository.CommentRepository, posts repository.PostRepository, entities repository.EntityRepository, notifier *Notifier, events realtime.Broker) *CommentHandler {
+	return &CommentHandler{repo: repo, posts: posts, entities: entities, notifier: notifier, events: events}
+}
+
+// publish tells everyone watching the post that a comment changed. Only the IDs go out:
+// a comment can be longer than a NOTIFY payload allows, so clients fetch it from /comments/:id.
+func (h *CommentHandler) publish(ctx context.Context, eventType string, comment core.Comment) {
+	realtime.Publish(ctx, h.events, realtime.PostTopic(comment.PostID), eventType,
+		fiber.Map{"id": comment.ID, "post_id": comment.PostID, "parent_id": comment.ParentID})
+}
+
+// POST /api/v1/protected/posts/:id/comments
+// Send parent_id to reply to another comment on the same post.
+func (h *CommentHandler) AddComment(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Post ID"})
+	}
+
+	var req core.CreateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	post, err := h.posts.GetPostByID(ctx, postID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("get post for comment: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add comment"})
+	}
+	if err != nil || !canViewPost(c, post, userID) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	var parentAuthor *uuid.UUID
+	if req.ParentID != nil {
+		parent, err := h.repo.GetByID(ctx, *req.ParentID, userID)
+		if err != nil || parent.PostID != postID || parent.Deleted {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Parent comment not found"})
+		}
+		if parent.Depth >= core.MaxCommentDepth {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Replies can be nested at most %d levels deep", core.MaxCommentDepth)})
+		}
+		parentAuthor = &parent.UserID
+	}
+
+	created, err := h.repo.CreateComment(ctx, core.Comment{
+		PostID:   postID,
+		UserID:   userID,
+		Content:  content,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Parent comment not found"})
+		}
+		fmt.Printf("create comment: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add comment"})
+	}
+	created.Entities = saveCommentEntities(ctx, h.entities, created.ID, created.Content)
+	h.notifyNewComment(ctx, created, parentAuthor)
+	h.publish(ctx, realtime.EventCommentCreated, created)
+
+	return c.Status(http.StatusCreated).JSON(created)
+}
+
+// GET /api/v1/protected/posts/:id/comments?page=1&limit=20&sort=top|newest|oldest
+// Only top-level comments are returned; each carries its reply_count.
+func (h *CommentHandler) GetComments(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Post ID"})
+	}
+
+	sort := strings.ToLower(c.Query("sort", core.CommentSortOldest))
+	if !core.IsValidCommentSort(sort) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "sort must be one of top, newest, oldest"})
+	}
+
+	page, limit := commentPagination(c)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	comments, total, err := h.repo.GetTopLevel(ctx, postID, userID, sort, limit, (page-1)*limit)
+	if err != nil {
+		fmt.Printf("get comments: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch comments"})
+	}
+
+	return c.Status(http.StatusOK).JSON(core.CommentPage{Comments: comments, Page: page, Limit: limit, Total: total})
+}
+
+// GET /api/v1/protected/comments/:id
+// Returns a single comment, e.g. one announced on the events stream.
+func (h *CommentHandler) GetComment(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Comment ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	comment, err := h.repo.GetByID(ctx, commentID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+		}
+		fmt.Printf("get comment: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch comment"})
+	}
+
+	return c.Status(http.StatusOK).JSON(comment)
+}
+
+// GET /api/v1/protected/comments/:id/replies?page=1&limit=20
+func (h *CommentHandler) GetReplies(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Comment ID"})
+	}
+
+	page, limit := commentPagination(c)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	replies, total, err := h.repo.GetReplies(ctx, commentID, userID, limit, (page-1)*limit)
+	if err != nil {
+		fmt.Printf("get replies: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch replies"})
+	}
+
+	return c.Status(http.StatusOK).JSON(core.CommentPage{Comments: replies, Page: page, Limit: limit, Total: total})
+}
+
+// PUT /api/v1/protected/comments/:id
+func (h *CommentHandler) UpdateComment(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Comment ID"})
+	}
+
+	var req core.UpdateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	comment, err := h.repo.GetByID(ctx, commentID, userID)
+	if err != nil || comment.Deleted {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	}
+	if comment.UserID != userID && !isModerator(c) {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "You cannot edit this comment"})
+	}
+
+	updated, err := h.repo.UpdateContent(ctx, commentID, userID, content)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+		}
+		fmt.Printf("update comment: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update comment"})
+	}
+	updated.Entities = saveCommentEntities(ctx, h.entities, updated.ID, updated.Content)
+	if updated.UserID == userID {
+		h.notifier.NotifyMentions(ctx, updated.Entities,
+			core.Notification{ActorID: &userID, PostID: &updated.PostID, CommentID: &updated.ID}, userID)
+	}
+	h.publish(ctx, realtime.EventCommentUpdated, updated)
+
+	return c.Status(http.StatusOK).JSON(updated)
+}
+
+// DELETE /api/v1/protected/comments/:id
+// Replies stay visible under a tombstone of the deleted comment.
+func (h *CommentHandler) DeleteComment(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Comment ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	comment, err := h.repo.GetByID(ctx, commentID, userID)
+	if err != nil || comment.Deleted {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+	}
+	if comment.UserID != userID && !isModerator(c) {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "You cannot delete this comment"})
+	}
+
+	if err := h.repo.SoftDelete(ctx, commentID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+		}
+		fmt.Printf("delete comment: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete comment"})
+	}
+	if err := h.entities.SaveCommentEntities(ctx, commentID, nil); err != nil {
+		fmt.Printf("clear comment entities: %v\n", err)
+	}
+	realtime.Publish(ctx, h.events, realtime.PostTopic(comment.PostID), realtime.EventCommentDeleted,
+		fiber.Map{"id": commentID, "post_id": comment.PostID})
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// POST /api/v1/protected/comments/:id/like
+func (h *CommentHandler) ToggleLike(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Comment ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	liked, err := h.repo.ToggleLike(ctx, userID, commentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Comment not found"})
+		}
+		fmt.Printf("toggle comment like: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to toggle like"})
+	}
+
+	comment, err := h.repo.GetByID(ctx, commentID, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to toggle like"})
+	}
+	realtime.Publish(ctx, h.events, realtime.PostTopic(comment.PostID), realtime.EventCommentLikeCount,
+		fiber.Map{"comment_id": commentID, "post_id": comment.PostID, "like_count": comment.LikeCount})
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"is_liked": liked, "like_count": comment.LikeCount})
+}
+
+// GET /api/v1/protected/comments/:id/like
+func (h *CommentHandler) CheckLikeStatus(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Comment ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	liked, err := h.repo.IsLiked(ctx, userID, commentID)
+	if err != nil {
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+	count, err := h.repo.GetLikeCount(ctx, commentID)
+	if err != nil {
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+
+	return c.JSON(fiber.Map{"is_liked": liked, "like_count": count})
+}
+
+func validateCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", errors.New("Content required")
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		return "", fmt.Errorf("Comments must be at most %d characters", maxCommentLength)
+	}
+	return content, nil
+}
+
+func commentPagination(c *fiber.Ctx) (int, int) {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > maxCommentPageSize {
+		limit = maxCommentPageSize
+	}
+	return page, limit
+}
+
+// isModerator reads the role claim stored by the auth middleware. The claim can be
+// trusted because a role change revokes the sessions whose tokens carry the old role.
+func isModerator(c *fiber.Ctx) bool {
+	role, _ := c.Locals("role").(string)
+	return role == core.RoleModerator || role == core.RoleAdmin
+}
+
+// notifyNewComment tells the parent's author about a reply and the post owner about
+// the comment, then the mentioned users. Nobody gets more than one notification.
+func (h *CommentHandler) notifyNewComment(ctx context.Context, comment core.Comment, parentAuthor *uuid.UUID) {
+	base := core.Notification{ActorID: &comment.UserID, PostID: &comment.PostID, CommentID: &comment.ID}
+	notified := []uuid.UUID{comment.UserID}
+
+	if parentAuthor != nil {
+		reply := base
+		reply.Type = core.NotificationReply
+		reply.RecipientID = *parentAuthor
+		h.notifier.Notify(ctx, reply)
+		notified = append(notified, *parentAuthor)
+	}
+
+	if owner, ok := h.notifier.PostOwner(ctx, comment.PostID); ok && (parentAuthor == nil || owner != *parentAuthor) {
+		onPost := base
+		onPost.Type = core.NotificationComment
+		onPost.RecipientID = owner
+		h.notifier.Notify(ctx, onPost)
+		notified = append(notified, owner)
+	}
+
+	h.notifier.NotifyMentions(ctx, comment.Entities, base, notified...)
+}
@@ -0,0 +1,188 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/api/throttle"
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	emailChangeCodeTTL   = 30 * time.Minute
+	emailChangeRevertTTL = 7 * 24 * time.Hour
+)
+
+type EmailChangeHandler struct {
+	repo      repository.EmailChangeRepository
+	userRepo  repository.UserRepository
+	codeGuard throttle.Guard
+}
+
+func NewEmailChangeHandler(repo repository.EmailChangeRepository, userRepo repository.UserRepository, attempts repository.LoginAttemptRepository) *EmailChangeHandler {
+	return &EmailChangeHandler{
+		repo:      repo,
+		userRepo:  userRepo,
+		codeGuard: throttle.NewGuard(attempts, "email_change"),
+	}
+}
+
+// POST /api/v1/protected/email-change
+// Starts a change by mailing a code to the new address. The account keeps its current email until confirmed.
+func (h *EmailChangeHandler) RequestEmailChange(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req core.EmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.NewEmail = strings.TrimSpace(strings.ToLower(req.NewEmail))
+
+	if addr, err := mail.ParseAddress(req.NewEmail); err != nil || addr.Address != req.NewEmail {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "A valid email address is required"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByIdForPassword(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "User not found"})
+	}
+	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Incorrect password"})
+	}
+
+	current, err := h.userRepo.GetById(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "User not found"})
+	}
+	if current.Email == req.NewEmail {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "This is already your email address"})
+	}
+
+	if _, err := h.userRepo.GetByEmail(ctx, req.NewEmail); err == nil {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "This email is already in use"})
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	otp, err := utils.GenerateOTP()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	otpHash, err := utils.HashCode(otp)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	change := core.EmailChange{
+		UserID:    userID,
+		OldEmail:  current.Email,
+		NewEmail:  req.NewEmail,
+		CodeHash:  otpHash,
+		ExpiresAt: time.Now().Add(emailChangeCodeTTL),
+	}
+	if err := h.repo.SavePending(ctx, change); err != nil {
+		if errors.Is(err, repository.ErrEmailChangeLocked) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Your email was changed recently. Please try again later."})
+		}
+		fmt.Printf("save email change: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	go func(targetEmail, code string) {
+		subject := "WonderTrip - Confirm your new email"
+		body := fmt.Sprintf("Hi,\n\nUse this code to confirm your new WonderTrip email address: %s\n\nThis code will be expired after 30 minutes. If you did not request this, you can ignore this email.", code)
+
+		if err := utils.SendEmail(targetEmail, subject, body); err != nil {
+			fmt.Printf("Email error for %s: %v\n", targetEmail, err)
+		}
+	}(req.NewEmail, otp)
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"message": "We sent a confirmation code to your new email address"})
+}
+
+// POST /api/v1/protected/email-change/confirm
+// Applies the change and sends the previous address a link that undoes it.
+func (h *EmailChangeHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req core.ConfirmEmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	account := userID.String()
+	if wait := h.codeGuard.LockedFor(ctx, account, c.IP()); wait > 0 {
+		return throttle.TooManyAttempts(c, wait)
+	}
+
+	change, err := h.repo.GetPending(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "No pending email change"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	if time.Now().After(change.ExpiresAt) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Code has expired"})
+	}
+	if !utils.VerifyHash(change.CodeHash, strings.TrimSpace(req.Code)) {
+		if wait := h.codeGuard.Fail(ctx, account, c.IP()); wait > 0 {
+			return throttle.TooManyAttempts(c, wait)
+		}
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Incorrect code"})
+	}
+	h.codeGuard.Succeed(ctx, account)
+
+	// The address may have been registered by someone else since the code was sent.
+	if _, err := h.userRepo.GetByEmail(ctx, change.NewEmail); err == nil {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "This email is already in use"})
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	revertToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	if err := h.repo.MarkConfirmed(ctx, userID, change.NewEmail, utils.HashToken(revertToken), time.Now().Add(emailChangeRevertTTL)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "No pending email change"})
+		}
+		fmt.Printf("confirm email change: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update email"})
+	}
+
+	revertURL := c.BaseURL() + "/api/v1/auth/email-change/revert?token=" + revertToken
+	go func(oldEmail, newEmail, link string) {
+		subject := "WonderTrip - Your email address was changed"
+		body := fmt.Sprintf("Hi,\n\nThe email address of your WonderTrip account was changed to %s.\n\nIf this wasn't you, open this link to restore your previous address and sign out every device:\n%s\n\nThis link will be expired after 7 days.", newEmail, link)
+
+		if err := utils.SendEmail(oldEmail, subject, body); err != nil {
+			fmt.Printf("Email error for %s: %v\n", oldEmail, err)
+		}
+	}(change.OldEmail, change.NewEmail, revertURL)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Email updated successfully", "email": change.NewEmail})
+}
@@ -0,0 +1,54 @@
+package private
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/google/uuid"
+)
+
+// parseEntities extracts the @mentions and #hashtags of text. Mentions of usernames
+// that do not exist are dropped so clients never link to a missing profile.
+func parseEntities(ctx context.Context, repo repository.EntityRepository, text string) core.TextEntities {
+	parsed := utils.ParseEntities(text)
+
+	ids, err := repo.ResolveUsernames(ctx, parsed.Usernames())
+	if err != nil {
+		fmt.Printf("resolve mentions: %v\n", err)
+		ids = nil
+	}
+
+	entities := make(core.TextEntities, 0, len(parsed))
+	for _, e := range parsed {
+		if e.Type == core.EntityMention {
+			id, ok := ids[e.Value]
+			if !ok {
+				continue
+			}
+			e.UserID = &id
+		}
+		entities = append(entities, e)
+	}
+	return entities
+}
+
+// savePostEntities is best effort: the post is already saved, so a failure is only logged.
+func savePostEntities(ctx context.Context, repo repository.EntityRepository, postID uuid.UUID, text string) core.TextEntities {
+	entities := parseEntities(ctx, repo, text)
+	if err := repo.SavePostEntities(ctx, postID, entities); err != nil {
+		fmt.Printf("save post entities: %v\n", err)
+	}
+	return entities
+}
+
+// saveCommentEntities is best effort: the comment is already saved, so a failure is only logged.
+func saveCommentEntities(ctx context.Context, repo repository.EntityRepository, commentID uuid.UUID, text string) core.TextEntities {
+	entities := parseEntities(ctx, repo, text)
+	if err := repo.SaveCommentEntities(ctx, commentID, entities); err != nil {
+		fmt.Printf("save comment entities: %v\n", err)
+	}
+	return entities
+}
@@ -0,0 +1,193 @@
+package private
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/realtime"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	maxStreamTopics     = 50
+	streamHeartbeat     = 25 * time.Second
+	streamMaxConnection = time.Hour
+)
+
+type EventsHandler struct {
+	broker   realtime.Broker
+	posts    repository.PostRepository
+	sessions repository.SessionRepository
+}
+
+func NewEventsHandler(broker realtime.Broker, posts repository.PostRepository, sessions repository.SessionRepository) *EventsHandler {
+	return &EventsHandler{broker: broker, posts: posts, sessions: sessions}
+}
+
+// GET /api/v1/protected/events?posts=<id>,<id>&users=<id>
+// A Server-Sent Events stream. The caller's own notifications are always included; posts
+// adds comment and like-count events for those posts, users adds their newly published posts.
+// Events only carry IDs and counts; clients fetch the comment or notification itself.
+// The stream ends when the access token expires or its session is revoked, so clients
+// reconnect with a fresh token.
+func (h *EventsHandler) Stream(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+	sessionID, err := utils.ParseUUID(c.Locals("sessionID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	postIDs, err := parseIDList(c.Query("posts"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID in posts"})
+	}
+	userIDs, err := parseIDList(c.Query("users"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID in users"})
+	}
+	if 1+len(postIDs)+len(userIDs) > maxStreamTopics {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("A stream may follow at most %d posts and users", maxStreamTopics-1)})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	// The middleware may have answered from its cache; a stream lives long enough to ask again.
+	if active, err := h.sessions.IsSessionActive(ctx, sessionID); err != nil || !active {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized: Session has been revoked"})
+	}
+
+	topics := []string{realtime.NotificationTopic(userID)}
+	for _, id := range postIDs {
+		post, err := h.posts.GetPostByID(ctx, id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("stream post %s: %v\n", id, err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+		}
+		// Hidden posts answer like missing ones so the stream cannot be used to probe for them.
+		if err != nil || !canViewPost(c, post, userID) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("Post %s not found", id)})
+		}
+		topics = append(topics, realtime.PostTopic(id))
+	}
+	for _, id := range userIDs {
+		topics = append(topics, realtime.UserTopic(id))
+	}
+
+	deadline := time.Now().Add(streamMaxConnection)
+	if exp, ok := c.Locals("expiresAt").(float64); ok {
+		if expiresAt := time.Unix(int64(exp), 0); expiresAt.Before(deadline) {
+			deadline = expiresAt
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Stops reverse proxies from holding events back in a buffer.
+	c.Set("X-Accel-Buffering", "no")
+
+	sub := h.broker.Subscribe(topics...)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		expired := time.NewTimer(time.Until(deadline))
+		defer expired.Stop()
+
+		if err := writeStreamEvent(w, "ready", fiber.Map{"topics": topics}); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-sub.C:
+				if !ok {
+					// Dropped for falling behind, or the server is shutting down.
+					return
+				}
+				if err := writeStreamEvent(w, event.Type, event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				active, err := h.sessionActive(sessionID)
+				if err != nil {
+					fmt.Printf("stream session check: %v\n", err)
+					return
+				}
+				if !active {
+					writeStreamEvent(w, "revoked", fiber.Map{})
+					return
+				}
+				// A comment line keeps proxies from closing an idle connection and
+				// tells us when the client has gone away.
+				if _, err := w.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			case <-expired.C:
+				writeStreamEvent(w, "expired", fiber.Map{})
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// sessionActive is checked on every heartbeat so logging out or revoking the session also
+// closes its streams. A failed check closes the stream too; the client reconnects through the middleware.
+func (h *EventsHandler) sessionActive(sessionID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return h.sessions.IsSessionActive(ctx, sessionID)
+}
+
+func writeStreamEvent(w *bufio.Writer, eventType string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, encoded); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func parseIDList(value string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := uuid.Parse(part)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
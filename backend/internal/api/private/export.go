@@ -0,0 +1,99 @@
+package private
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// exportPhotoLinkTTL is how long photo links in an export keep working. Exports are opened
+// in other tools without our login, so the links carry their own signed grant.
+const exportPhotoLinkTTL = 30 * 24 * time.Hour
+
+// GET /api/v1/protected/posts/me/export?format=geojson|gpx|kml
+func (h *PostHandler) ExportMyPosts(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	format, err := utils.ParseGeoFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	posts, err := h.repo.GetPostsByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch posts"})
+	}
+
+	return h.streamPlaces(c, format, "wondertrip-posts", "My WonderTrip places", posts)
+}
+
+// GET /api/v1/protected/favorites/export?format=geojson|gpx|kml
+func (h *PostHandler) ExportFavorites(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	format, err := utils.ParseGeoFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	posts, err := h.repo.GetFavorites(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch favorites"})
+	}
+
+	return h.streamPlaces(c, format, "wondertrip-favorites", "My WonderTrip favorites", posts)
+}
+
+func (h *PostHandler) streamPlaces(c *fiber.Ctx, format utils.GeoFormat, filePrefix, title string, posts []core.Post) error {
+	photoBase := c.BaseURL() + "/api/v1/photos/"
+	expiresAt := time.Now().Add(exportPhotoLinkTTL).Unix()
+	photoURL := func(p core.Post) string {
+		if p.PhotoPath == "" {
+			return ""
+		}
+		name := filepath.Base(p.PhotoPath)
+		token, err := h.signer.Sign(tokens.TypePhoto, jwt.MapClaims{"file": name, "exp": expiresAt})
+		if err != nil {
+			fmt.Printf("sign photo link: %v\n", err)
+			return ""
+		}
+		return photoBase + name + "?token=" + token
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", filePrefix, time.Now().Format("20060102"), format.Extension())
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := utils.WritePlaces(w, format, title, posts, photoURL); err != nil {
+			fmt.Printf("export places: %v\n", err)
+			return
+		}
+		if err := w.Flush(); err != nil {
+			fmt.Printf("export places flush: %v\n", err)
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const maxFollowPageSize = 50
+
+type FollowHandler struct {
+	repo     repository.FollowRepository
+	notifier *Notifier
+}
+
+func NewFollowHandler(repo repository.FollowRepository, notifier *Notifier) *FollowHandler {
+	return &FollowHandler{repo: repo, notifier: notifier}
+}
+
+// POST /api/v1/protected/users/:id/follow
+func (h *FollowHandler) Follow(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	targetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+	if targetID == userID {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "You cannot follow yourself"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	created, err := h.repo.Follow(ctx, userID, targetID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		fmt.Printf("follow: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to follow user"})
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		h.notifier.Notify(ctx, core.Notification{RecipientID: targetID, ActorID: &userID, Type: core.NotificationFollow})
+	}
+	return c.Status(status).JSON(fiber.Map{"following": true})
+}
+
+// DELETE /api/v1/protected/users/:id/follow
+func (h *FollowHandler) Unfollow(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	targetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	removed, err := h.repo.Unfollow(ctx, userID, targetID)
+	if err != nil {
+		fmt.Printf("unfollow: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to unfollow user"})
+	}
+	if removed {
+		h.notifier.Retract(ctx, core.Notification{RecipientID: targetID, ActorID: &userID, Type: core.NotificationFollow})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"following": false})
+}
+
+// GET /api/v1/protected/users/:id/followers?page=1&limit=20
+func (h *FollowHandler) GetFollowers(c *fiber.Ctx) error {
+	return h.list(c, h.repo.GetFollowers, func(counts core.FollowCounts) int { return counts.Followers })
+}
+
+// GET /api/v1/protected/users/:id/following?page=1&limit=20
+func (h *FollowHandler) GetFollowing(c *fiber.Ctx) error {
+	return h.list(c, h.repo.GetFollowing, func(counts core.FollowCounts) int { return counts.Following })
+}
+
+type followListFunc func(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]core.FollowUser, error)
+
+func (h *FollowHandler) list(c *fiber.Ctx, fetch followListFunc, total func(core.FollowCounts) int) error {
+	viewerID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	targetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > maxFollowPageSize {
+		limit = maxFollowPageSize
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	users, err := fetch(ctx, targetID, viewerID, limit, (page-1)*limit)
+	if err != nil {
+		fmt.Printf("list follows: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch users"})
+	}
+
+	counts, err := h.repo.GetCounts(ctx, targetID)
+	if err != nil {
+		fmt.Printf("list follows: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch users"})
+	}
+
+	return c.Status(http.StatusOK).JSON(core.FollowListResponse{
+		Users: users,
+		Page:  page,
+		Limit: limit,
+		Total: total(counts),
+	})
+}
@@ -0,0 +1,151 @@
+package private
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// Kept under Fiber's default 4 MB body limit with room for the multipart envelope,
+	// so oversized files get this handler's error instead of a bare 413.
+	maxImportFileBytes = 3 << 20
+	maxImportItems     = 500
+)
+
+// POST /api/v1/protected/posts/import
+// Accepts a "file" form field holding GeoJSON or GPX and creates one draft post per place.
+// Items that fail validation are reported in the response; the rest are still imported.
+func (h *PostHandler) ImportPosts(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Import file is required"})
+	}
+	if fileHeader.Size > maxImportFileBytes {
+		return c.Status(http.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Import file must be 3 MB or smaller"})
+	}
+
+	format, err := importFormat(c.Query("format"), fileHeader.Filename)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Import file could not be read"})
+	}
+	defer file.Close()
+
+	places, err := utils.ParsePlaces(file, format)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(places) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "No places found in file"})
+	}
+	if len(places) > maxImportItems {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("A single import may contain at most %d places", maxImportItems)})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 30*time.Second)
+	defer cancel()
+
+	response := core.ImportResponse{Results: make([]core.ImportItemResult, 0, len(places))}
+	for i, place := range places {
+		result := core.ImportItemResult{Index: i, Title: strings.TrimSpace(place.Title)}
+
+		post, err := draftFromPlace(userID, place)
+		if err == nil {
+			var created core.Post
+			created, err = h.repo.CreatePost(ctx, post)
+			if err != nil {
+				fmt.Printf("import post %d: %v\n", i, err)
+				err = errors.New("Post creation failed")
+			} else {
+				result.PostID = &created.ID
+				savePostEntities(ctx, h.entities, created.ID, created.Description)
+			}
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			response.Created++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	status := http.StatusCreated
+	if response.Created == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	return c.Status(status).JSON(response)
+}
+
+func draftFromPlace(userID uuid.UUID, place core.ImportedPlace) (core.Post, error) {
+	if place.Problem != "" {
+		return core.Post{}, errors.New(place.Problem)
+	}
+
+	lat, lng := place.Latitude, place.Longitude
+	req := core.PostPublishReq{
+		Title:       place.Title,
+		Description: place.Description,
+		Rating:      place.Rating,
+		Coordinates: strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64),
+		Draft:       true,
+	}
+	sanitizePostRequest(&req)
+
+	rating := 0
+	if req.Rating != nil {
+		rating = *req.Rating
+	}
+	if err := validatePublishRequest(req, rating); err != nil {
+		return core.Post{}, err
+	}
+	if !utils.ValidLatLng(lat, lng) {
+		return core.Post{}, utils.ErrInvalidCoordinates
+	}
+
+	return core.Post{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+		Rating:      rating,
+		Coordinates: req.Coordinates,
+		Latitude:    &lat,
+		Longitude:   &lng,
+		Draft:       true,
+	}, nil
+}
+
+// importFormat prefers an explicit ?format= and otherwise goes by the file extension.
+func importFormat(query, filename string) (utils.GeoFormat, error) {
+	value := query
+	if value == "" {
+		value = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	format, err := utils.ParseGeoFormat(value)
+	if err != nil || format == utils.FormatKML {
+		return "", errors.New("Import supports GeoJSON and GPX files")
+	}
+	return format, nil
+}
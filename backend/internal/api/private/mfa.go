@@ -0,0 +1,270 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/api/throttle"
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaIssuer         = "WonderTrip"
+	recoveryCodeCount = 10
+)
+
+type MFAHandler struct {
+	repo          repository.MFARepository
+	userRepo      repository.UserRepository
+	passwordGuard throttle.Guard
+	codeGuard     throttle.Guard
+}
+
+// The code guard shares its scope with the 2FA login step, so guesses made here and there
+// count against the same budget for the one secret they target.
+func NewMFAHandler(repo repository.MFARepository, userRepo repository.UserRepository, attempts repository.LoginAttemptRepository) *MFAHandler {
+	return &MFAHandler{
+		repo:          repo,
+		userRepo:      userRepo,
+		passwordGuard: throttle.NewGuard(attempts, "mfa_password"),
+		codeGuard:     throttle.NewGuard(attempts, "mfa"),
+	}
+}
+
+// GET /api/v1/protected/mfa
+func (h *MFAHandler) GetStatus(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	mfa, err := h.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusOK).JSON(core.MFAStatus{})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	if !mfa.Enabled() {
+		return c.Status(http.StatusOK).JSON(core.MFAStatus{})
+	}
+
+	remaining, err := h.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(core.MFAStatus{
+		Enabled:                true,
+		EnabledAt:              mfa.EnabledAt,
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// POST /api/v1/protected/mfa/enroll
+// Returns a fresh secret to add to an authenticator app. 2FA stays off until /mfa/confirm.
+func (h *MFAHandler) Enroll(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req core.MFAEnrollRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if ok, err := h.checkPassword(ctx, c, userID, req.Password); !ok {
+		return err
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	if err := h.repo.SaveEnrollment(ctx, userID, secret); err != nil {
+		if errors.Is(err, repository.ErrMFAAlreadyEnabled) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		fmt.Printf("mfa enroll: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	account, _ := c.Locals("email").(string)
+	return c.Status(http.StatusOK).JSON(core.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPURI(mfaIssuer, account, secret),
+	})
+}
+
+// POST /api/v1/protected/mfa/confirm
+// Turns 2FA on once the app produces a valid code and hands out recovery codes, shown only this once.
+func (h *MFAHandler) Confirm(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req core.MFAConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	mfa, err := h.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Start enrollment first"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	if mfa.Enabled() {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": repository.ErrMFAAlreadyEnabled.Error()})
+	}
+
+	step, ok := utils.ValidateTOTP(mfa.Secret, req.Code, time.Now())
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Incorrect code"})
+	}
+
+	codes, stored, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	if err := h.repo.Enable(ctx, userID, step, stored); err != nil {
+		if errors.Is(err, repository.ErrMFAAlreadyEnabled) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		fmt.Printf("mfa enable: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// POST /api/v1/protected/mfa/disable
+// Needs the password and a current code, so a stolen session alone cannot turn 2FA off.
+func (h *MFAHandler) Disable(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req core.MFADisableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if ok, err := h.checkPassword(ctx, c, userID, req.Password); !ok {
+		return err
+	}
+
+	mfa, err := h.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Two-factor authentication is not enabled"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	if mfa.Enabled() {
+		account := userID.String()
+		if wait := h.codeGuard.LockedFor(ctx, account, c.IP()); wait > 0 {
+			return throttle.TooManyAttempts(c, wait)
+		}
+		step, ok := utils.ValidateTOTP(mfa.Secret, req.Code, time.Now())
+		if ok {
+			fresh, err := h.repo.MarkStepUsed(ctx, userID, step)
+			ok = err == nil && fresh
+		}
+		if !ok {
+			if wait := h.codeGuard.Fail(ctx, account, c.IP()); wait > 0 {
+				return throttle.TooManyAttempts(c, wait)
+			}
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Incorrect code"})
+		}
+		h.codeGuard.Succeed(ctx, account)
+	}
+
+	if err := h.repo.Disable(ctx, userID); err != nil {
+		fmt.Printf("mfa disable: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Two-factor authentication disabled"})
+}
+
+// checkPassword re-checks the password behind a signed-in session. When it reports false the
+// error response has already been written. Failures are throttled so a stolen session cannot guess the password.
+func (h *MFAHandler) checkPassword(ctx context.Context, c *fiber.Ctx, userID uuid.UUID, password string) (bool, error) {
+	account := userID.String()
+	if wait := h.passwordGuard.LockedFor(ctx, account, c.IP()); wait > 0 {
+		return false, throttle.TooManyAttempts(c, wait)
+	}
+
+	user, err := h.userRepo.GetByIdForPassword(ctx, userID)
+	if err != nil {
+		return false, c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "User not found"})
+	}
+	if !utils.CheckPasswordHash(password, user.PasswordHash) {
+		if wait := h.passwordGuard.Fail(ctx, account, c.IP()); wait > 0 {
+			return false, throttle.TooManyAttempts(c, wait)
+		}
+		return false, c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Incorrect password"})
+	}
+	h.passwordGuard.Succeed(ctx, account)
+	return true, nil
+}
+
+// newRecoveryCodes returns the plain codes for the user and their hashed form for storage.
+// bcrypt at HashCode's cost is slow, so the codes are hashed in parallel.
+func newRecoveryCodes() ([]string, []core.RecoveryCode, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored := make([]core.RecoveryCode, len(codes))
+	errs := make([]error, len(codes))
+	var wg sync.WaitGroup
+	for i, code := range codes {
+		wg.Add(1)
+		go func(i int, code string) {
+			defer wg.Done()
+			hash, err := utils.HashCode(code)
+			stored[i] = core.RecoveryCode{Prefix: utils.RecoveryCodePrefix(code), CodeHash: hash}
+			errs[i] = err
+		}(i, code)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	return codes, stored, nil
+}
@@ -0,0 +1,226 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/realtime"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	maxRejectionReasonLength = 500
+	maxBulkModerationPosts   = 100
+)
+
+type ModerationHandler struct {
+	repo     repository.ModerationRepository
+	posts    repository.PostRepository
+	notifier *Notifier
+	events   realtime.Broker
+}
+
+func NewModerationHandler(repo repository.ModerationRepository, posts repository.PostRepository, notifier *Notifier, events realtime.Broker) *ModerationHandler {
+	return &ModerationHandler{repo: repo, posts: posts, notifier: notifier, events: events}
+}
+
+// notifyDecision tells the post's author how their post was moderated; a rejection carries the reason.
+// An approved post also goes out to everyone following its author's live stream, and only then are
+// the users mentioned in it told, so nobody hears about a post that moderation turns down.
+func (h *ModerationHandler) notifyDecision(ctx context.Context, postID, moderatorID uuid.UUID, status, reason string) {
+	owner, ok := h.notifier.PostOwner(ctx, postID)
+	if !ok {
+		return
+	}
+
+	notificationType := core.NotificationPostApproved
+	if status == core.ModerationRejected {
+		notificationType = core.NotificationPostRejected
+	}
+	h.notifier.Notify(ctx, core.Notification{
+		RecipientID: owner,
+		ActorID:     &moderatorID,
+		Type:        notificationType,
+		PostID:      &postID,
+		Message:     reason,
+	})
+
+	if status != core.ModerationApproved {
+		return
+	}
+	realtime.Publish(ctx, h.events, realtime.UserTopic(owner), realtime.EventPostPublished,
+		fiber.Map{"post_id": postID, "user_id": owner})
+
+	post, err := h.posts.GetPostByID(ctx, postID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("load approved post: %v\n", err)
+		}
+		return
+	}
+	h.notifier.NotifyMentions(ctx, post.Entities, core.Notification{ActorID: &post.UserID, PostID: &post.ID})
+}
+
+// POST /api/v1/admin/posts/:id/approve
+func (h *ModerationHandler) ApprovePost(c *fiber.Ctx) error {
+	return h.moderateOne(c, core.ModerationApproved, "")
+}
+
+// POST /api/v1/admin/posts/:id/reject
+func (h *ModerationHandler) RejectPost(c *fiber.Ctx) error {
+	var req core.RejectPostRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	reason, err := validateRejectionReason(req.Reason)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return h.moderateOne(c, core.ModerationRejected, reason)
+}
+
+func (h *ModerationHandler) moderateOne(c *fiber.Ctx, status, reason string) error {
+	moderatorID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Post ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repo.SetStatus(ctx, postID, moderatorID, status, reason); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+		}
+		fmt.Printf("moderate post: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to moderate post"})
+	}
+	h.notifyDecision(ctx, postID, moderatorID, status, reason)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"id":                postID,
+		"moderation_status": status,
+	})
+}
+
+// POST /api/v1/admin/posts/moderate
+func (h *ModerationHandler) BulkModerate(c *fiber.Ctx) error {
+	moderatorID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	var req core.BulkModerationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if len(req.PostIDs) == 0 || len(req.PostIDs) > maxBulkModerationPosts {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("post_ids must contain between 1 and %d posts", maxBulkModerationPosts)})
+	}
+
+	var status, reason string
+	switch req.Action {
+	case "approve":
+		status = core.ModerationApproved
+	case "reject":
+		status = core.ModerationRejected
+		if reason, err = validateRejectionReason(req.Reason); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "action must be 'approve' or 'reject'"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.repo.BulkSetStatus(ctx, req.PostIDs, moderatorID, status, reason)
+	if err != nil {
+		fmt.Printf("bulk moderate: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to moderate posts"})
+	}
+
+	updatedSet := make(map[uuid.UUID]bool, len(updated))
+	for _, id := range updated {
+		updatedSet[id] = true
+		h.notifyDecision(ctx, id, moderatorID, status, reason)
+	}
+	notFound := []uuid.UUID{}
+	for _, id := range req.PostIDs {
+		if !updatedSet[id] {
+			notFound = append(notFound, id)
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"moderation_status": status,
+		"updated":           updated,
+		"not_found":         notFound,
+	})
+}
+
+// GET /api/v1/protected/posts/:id/moderation-history
+// Visible to the post's author and to moderators, including after the post was deleted.
+func (h *ModerationHandler) GetPostHistory(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Post ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	role, _ := c.Locals("role").(string)
+	if role != core.RoleModerator && role != core.RoleAdmin {
+		ownerID, err := h.repo.GetPostOwner(ctx, postID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+			}
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch moderation history"})
+		}
+		if ownerID != userID {
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "You do not own this post"})
+		}
+	}
+
+	history, err := h.repo.GetPostHistory(ctx, postID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch moderation history"})
+	}
+
+	return c.Status(http.StatusOK).JSON(history)
+}
+
+func validateRejectionReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", errors.New("A reason is required to reject a post")
+	}
+	if len([]rune(reason)) > maxRejectionReasonLength {
+		return "", fmt.Errorf("Reason must be at most %d characters", maxRejectionReasonLength)
+	}
+	return reason, nil
+}
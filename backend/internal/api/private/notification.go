@@ -0,0 +1,235 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 50
+)
+
+var errInvalidCursor = errors.New("Invalid cursor")
+
+type NotificationHandler struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationHandler(repo repository.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{repo: repo}
+}
+
+// GET /api/v1/protected/notifications?limit=20&cursor=...&unread_only=true
+// Pass the returned next_cursor to get the following page; it is omitted on the last one.
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultNotificationPageSize)))
+	if limit < 1 {
+		limit = defaultNotificationPageSize
+	}
+	if limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
+	var after *core.NotificationCursor
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeNotificationCursor(raw)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		after = &cursor
+	}
+
+	unreadOnly := c.QueryBool("unread_only", false)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	// One extra row tells us whether another page exists.
+	notifications, err := h.repo.List(ctx, userID, after, limit+1, unreadOnly)
+	if err != nil {
+		fmt.Printf("list notifications: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch notifications"})
+	}
+
+	page := core.NotificationPage{Notifications: notifications}
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		last := page.Notifications[limit-1]
+		page.NextCursor = encodeNotificationCursor(core.NotificationCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	page.UnreadCount, err = h.repo.UnreadCount(ctx, userID)
+	if err != nil {
+		fmt.Printf("count unread notifications: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch notifications"})
+	}
+
+	return c.Status(http.StatusOK).JSON(page)
+}
+
+// GET /api/v1/protected/notifications/unread-count
+func (h *NotificationHandler) GetUnreadCount(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	count, err := h.repo.UnreadCount(ctx, userID)
+	if err != nil {
+		fmt.Printf("count unread notifications: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count notifications"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"unread_count": count})
+}
+
+// POST /api/v1/protected/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	notificationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Notification ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repo.MarkRead(ctx, userID, notificationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+		}
+		fmt.Printf("mark notification read: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notification"})
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// POST /api/v1/protected/notifications/read-all
+func (h *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	marked, err := h.repo.MarkAllRead(ctx, userID)
+	if err != nil {
+		fmt.Printf("mark all notifications read: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notifications"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"marked": marked})
+}
+
+// GET /api/v1/protected/notifications/preferences
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	muted, err := h.repo.GetMutedTypes(ctx, userID)
+	if err != nil {
+		fmt.Printf("get notification preferences: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch preferences"})
+	}
+
+	return c.Status(http.StatusOK).JSON(core.NotificationPreferences{Muted: muted})
+}
+
+// PUT /api/v1/protected/notifications/preferences
+// The muted list replaces the stored one; send an empty list to unmute everything.
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	var req core.NotificationPreferences
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	muted := make([]string, 0, len(req.Muted))
+	seen := map[string]bool{}
+	for _, t := range req.Muted {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !core.IsValidNotificationType(t) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Unknown notification type %q", t)})
+		}
+		if !seen[t] {
+			seen[t] = true
+			muted = append(muted, t)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repo.SetMutedTypes(ctx, userID, muted); err != nil {
+		fmt.Printf("update notification preferences: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update preferences"})
+	}
+
+	return c.Status(http.StatusOK).JSON(core.NotificationPreferences{Muted: muted})
+}
+
+// Cursors are opaque to clients: base64url of "<created_at unix nanos>:<id>".
+func encodeNotificationCursor(cursor core.NotificationCursor) string {
+	raw := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + ":" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeNotificationCursor(value string) (core.NotificationCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return core.NotificationCursor{}, errInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return core.NotificationCursor{}, errInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return core.NotificationCursor{}, errInvalidCursor
+	}
+	notificationID, err := uuid.Parse(id)
+	if err != nil {
+		return core.NotificationCursor{}, errInvalidCursor
+	}
+
+	return core.NotificationCursor{CreatedAt: time.Unix(0, unixNano), ID: notificationID}, nil
+}
@@ -0,0 +1,118 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/realtime"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Notifier writes in-app notifications for the handlers that cause them and pushes
+// each one to the recipient's live event stream.
+// Notifying is best effort: failures are logged and never fail the request.
+// A nil *Notifier does nothing, so handlers can be built without one.
+type Notifier struct {
+	repo   repository.NotificationRepository
+	broker realtime.Broker
+}
+
+func NewNotifier(repo repository.NotificationRepository, broker realtime.Broker) *Notifier {
+	return &Notifier{repo: repo, broker: broker}
+}
+
+// Notify stores n unless the actor would be notifying themselves.
+func (n *Notifier) Notify(ctx context.Context, notification core.Notification) {
+	if n == nil {
+		return
+	}
+	if notification.ActorID != nil && *notification.ActorID == notification.RecipientID {
+		return
+	}
+
+	created, err := n.repo.Create(ctx, notification)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("notify %s: %v\n", notification.Type, err)
+		}
+		return
+	}
+	// Like every live event this carries IDs only; the text is fetched from /notifications.
+	realtime.Publish(ctx, n.broker, realtime.NotificationTopic(created.RecipientID), realtime.EventNotification,
+		fiber.Map{"id": created.ID, "type": created.Type, "actor_id": created.ActorID, "post_id": created.PostID, "comment_id": created.CommentID})
+}
+
+// PostOwner looks up who wrote postID; ok is false when that is unknown.
+func (n *Notifier) PostOwner(ctx context.Context, postID uuid.UUID) (uuid.UUID, bool) {
+	if n == nil {
+		return uuid.UUID{}, false
+	}
+
+	owner, err := n.repo.GetPostOwner(ctx, postID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("get post owner: %v\n", err)
+		}
+		return uuid.UUID{}, false
+	}
+	return owner, true
+}
+
+// NotifyPostOwner sends notification to whoever wrote postID.
+func (n *Notifier) NotifyPostOwner(ctx context.Context, postID uuid.UUID, notification core.Notification) {
+	owner, ok := n.PostOwner(ctx, postID)
+	if !ok {
+		return
+	}
+
+	notification.RecipientID = owner
+	notification.PostID = &postID
+	n.Notify(ctx, notification)
+}
+
+// RetractFromPostOwner takes back an unread notification, e.g. after an unlike.
+func (n *Notifier) RetractFromPostOwner(ctx context.Context, postID, actorID uuid.UUID, notificationType string) {
+	owner, ok := n.PostOwner(ctx, postID)
+	if !ok {
+		return
+	}
+	n.Retract(ctx, core.Notification{RecipientID: owner, ActorID: &actorID, Type: notificationType, PostID: &postID})
+}
+
+func (n *Notifier) Retract(ctx context.Context, notification core.Notification) {
+	if n == nil {
+		return
+	}
+	if err := n.repo.Retract(ctx, notification); err != nil {
+		fmt.Printf("retract %s: %v\n", notification.Type, err)
+	}
+}
+
+// NotifyMentions tells every user mentioned in entities, skipping skip (usually
+// someone who already got a more specific notification about the same thing).
+func (n *Notifier) NotifyMentions(ctx context.Context, entities core.TextEntities, base core.Notification, skip ...uuid.UUID) {
+	if n == nil {
+		return
+	}
+
+	notified := map[uuid.UUID]bool{}
+	for _, id := range skip {
+		notified[id] = true
+	}
+	for _, e := range entities {
+		if e.Type != core.EntityMention || e.UserID == nil || notified[*e.UserID] {
+			continue
+		}
+		notified[*e.UserID] = true
+
+		mention := base
+		mention.Type = core.NotificationMention
+		mention.RecipientID = *e.UserID
+		n.Notify(ctx, mention)
+	}
+}
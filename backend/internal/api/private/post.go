@@ -2,10 +2,12 @@ package private
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -13,17 +15,54 @@ import (
 	"time"
 
 	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/realtime"
 	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
+	"github.com/TeamA166/WonderTrip/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+const maxNearbyRadiusKm = 100
+
+const (
+	// At this zoom and closer the map gets individual posts instead of clusters.
+	mapClusterMaxZoom = 14
+	mapMaxZoom        = 22
+	mapPostLimit      = 500
+)
+
 type PostHandler struct {
-	repo repository.PostRepository
+	repo           repository.PostRepository
+	moderationRepo repository.ModerationRepository
+	entities       repository.EntityRepository
+	notifier       *Notifier
+	events         realtime.Broker
+	signer         *tokens.Signer
 }
 
-func NewPostHandler(repo repository.PostRepository) *PostHandler {
-	return &PostHandler{repo: repo}
+func NewPostHandler(repo repository.PostRepository, moderationRepo repository.ModerationRepository, entities repository.EntityRepository, notifier *Notifier, events realtime.Broker, signer *tokens.Signer) *PostHandler {
+	return &PostHandler{repo: repo, moderationRepo: moderationRepo, entities: entities, notifier: notifier, events: events, signer: signer}
+}
+
+// recordModerationEvent writes to the post's moderation history. A failure here must not
+// undo a change the user already made, so it is only logged.
+func (h *PostHandler) recordModerationEvent(ctx context.Context, post core.Post, actorID uuid.UUID, eventType, fromStatus, reason string) {
+	event := core.ModerationEvent{
+		PostID:      post.ID,
+		PostOwnerID: &post.UserID,
+		ActorID:     &actorID,
+		EventType:   eventType,
+		FromStatus:  fromStatus,
+		Reason:      reason,
+	}
+	if eventType != core.EventDeleted {
+		event.ToStatus = core.ModerationPending
+	}
+
+	if err := h.moderationRepo.RecordEvent(ctx, event); err != nil {
+		fmt.Printf("moderation event: %v\n", err)
+	}
 }
 
 func (h *PostHandler) Publish(c *fiber.Ctx) error {
@@ -31,6 +70,7 @@ func (h *PostHandler) Publish(c *fiber.Ctx) error {
 	req.Title = c.FormValue("title")
 	req.Description = c.FormValue("description")
 	req.Coordinates = c.FormValue("coordinates")
+	req.CountryCode = c.FormValue("country_code")
 
 	rating, ratingProvided, err := parseRating(c.FormValue("rating"))
 	if err != nil {
@@ -40,18 +80,35 @@ func (h *PostHandler) Publish(c *fiber.Ctx) error {
 		req.Rating = &rating
 	}
 
+	draft, err := parseDraftFlag(c.FormValue("draft"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid draft value"})
+	}
+	req.Draft = draft
+
 	sanitizePostRequest(&req)
 
 	if err := validatePublishRequest(req, rating); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	file, err := c.FormFile("photo")
+	lat, lng, err := utils.ParseCoordinates(req.Coordinates)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	countryCode, err := utils.ParseCountryCode(req.CountryCode)
 	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Drafts may be saved without a photo; it becomes mandatory once the draft is submitted.
+	file, err := c.FormFile("photo")
+	if err != nil && !req.Draft {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Photo file is required"})
 	}
 
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Authentication failed"})
 	}
@@ -59,10 +116,13 @@ func (h *PostHandler) Publish(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	photoPath, err := savePhoto(c, file)
-	if err != nil {
-		fmt.Printf("save photo: %v", err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Photo could not be saved"})
+	var photoPath string
+	if file != nil {
+		photoPath, err = savePhoto(c, file)
+		if err != nil {
+			fmt.Printf("save photo: %v", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Photo could not be saved"})
+		}
 	}
 
 	if req.Rating == nil {
@@ -79,7 +139,11 @@ func (h *PostHandler) Publish(c *fiber.Ctx) error {
 		Description: req.Description,
 		Rating:      rating,
 		Coordinates: req.Coordinates,
+		Latitude:    &lat,
+		Longitude:   &lng,
+		CountryCode: countryCode,
 		PhotoPath:   photoPath,
+		Draft:       req.Draft,
 	}
 
 	created, err := h.repo.CreatePost(ctx, post)
@@ -88,6 +152,12 @@ func (h *PostHandler) Publish(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Post creation failed"})
 	}
 
+	// Drafts are not in the moderation queue yet; their history starts when they are submitted.
+	if !created.Draft {
+		h.recordModerationEvent(ctx, created, userID, core.EventCreated, "", "")
+	}
+	created.Entities = savePostEntities(ctx, h.entities, created.ID, created.Description)
+
 	return c.Status(http.StatusCreated).JSON(created)
 }
 
@@ -103,7 +173,7 @@ func validatePublishRequest(req core.PostPublishReq, rating int) error {
 		return errors.New("Title is required")
 	}
 
-	if req.Description == "" {
+	if req.Description == "" && !req.Draft {
 		return errors.New("Description is required")
 	}
 
@@ -118,6 +188,13 @@ func validatePublishRequest(req core.PostPublishReq, rating int) error {
 	return nil
 }
 
+func parseDraftFlag(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
 func parseRating(value string) (int, bool, error) {
 	if value == "" {
 		return 0, false, nil
@@ -131,15 +208,6 @@ func parseRating(value string) (int, bool, error) {
 	return parsed, true, nil
 }
 
-func parseUserID(value interface{}) (uuid.UUID, error) {
-	if value == nil {
-		return uuid.UUID{}, errors.New("user id missing")
-	}
-
-	userIDStr := fmt.Sprint(value)
-	return uuid.Parse(userIDStr)
-}
-
 func savePhoto(c *fiber.Ctx, file *multipart.FileHeader) (string, error) {
 	const uploadDir = "uploads/photos"
 
@@ -185,6 +253,91 @@ func (h *PostHandler) fetchPostsWithPagination(c *fiber.Ctx, wantVerified bool)
 	return c.Status(http.StatusOK).JSON(posts)
 }
 
+// GET /api/v1/protected/posts/nearby?lat=&lng=&radius_km=
+func (h *PostHandler) GetNearbyPosts(c *fiber.Ctx) error {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil || !utils.ValidLatLng(lat, lng) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Valid lat and lng are required"})
+	}
+
+	radiusKm, err := strconv.ParseFloat(c.Query("radius_km", "5"), 64)
+	if err != nil || radiusKm <= 0 || radiusKm > maxNearbyRadiusKm {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("radius_km must be between 0 and %d", maxNearbyRadiusKm)})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	posts, err := h.repo.GetNearbyPosts(ctx, lat, lng, radiusKm, limit)
+	if err != nil {
+		fmt.Printf("nearby posts: %v", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch nearby posts"})
+	}
+
+	return c.Status(http.StatusOK).JSON(posts)
+}
+
+// GET /api/v1/protected/posts/map?bbox=minLng,minLat,maxLng,maxLat&zoom=
+func (h *PostHandler) GetMapPosts(c *fiber.Ctx) error {
+	box, err := utils.ParseBoundingBox(c.Query("bbox"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	zoom, err := strconv.Atoi(c.Query("zoom", "0"))
+	if err != nil || zoom < 0 || zoom > mapMaxZoom {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("zoom must be between 0 and %d", mapMaxZoom)})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	response := core.MapResponse{
+		Zoom:     zoom,
+		Clusters: []core.PostCluster{},
+		Posts:    []core.Post{},
+	}
+
+	if zoom < mapClusterMaxZoom {
+		clusters, err := h.repo.GetPostClusters(ctx, box, clusterCellDegrees(zoom))
+		if err != nil {
+			fmt.Printf("map clusters: %v", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch map data"})
+		}
+		response.Clustered = true
+		response.Clusters = clusters
+		return c.Status(http.StatusOK).JSON(response)
+	}
+
+	// Ask for one extra row so we can tell the app the viewport was cut off.
+	posts, err := h.repo.GetPostsInBounds(ctx, box, mapPostLimit+1)
+	if err != nil {
+		fmt.Printf("map posts: %v", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch map data"})
+	}
+	if len(posts) > mapPostLimit {
+		posts = posts[:mapPostLimit]
+		response.Truncated = true
+	}
+	if posts != nil {
+		response.Posts = posts
+	}
+
+	return c.Status(http.StatusOK).JSON(response)
+}
+
+// clusterCellDegrees splits the world width at the given zoom into cells of roughly
+// a quarter of a 256px map tile, which keeps markers from overlapping on screen.
+func clusterCellDegrees(zoom int) float64 {
+	return 360 / float64(int64(1)<<zoom) / 4
+}
+
 func (h *PostHandler) GetPostPhoto(c *fiber.Ctx) error {
 
 	filename := c.Params("filename")
@@ -210,7 +363,7 @@ func containsDotDot(v string) bool {
 
 // GET /api/v1/protected/posts/me
 func (h *PostHandler) GetMyPosts(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 	}
@@ -228,7 +381,7 @@ func (h *PostHandler) GetMyPosts(c *fiber.Ctx) error {
 
 // DELETE /api/v1/protected/posts/:id
 func (h *PostHandler) DeletePost(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -241,17 +394,26 @@ func (h *PostHandler) DeletePost(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
+	post, err := h.repo.GetPostByID(ctx, postID)
+	if err != nil || post.UserID != userID {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "post not found or unauthorized"})
+	}
+
 	if err := h.repo.DeletePost(ctx, postID, userID); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if !post.Draft {
+		h.recordModerationEvent(ctx, post, userID, core.EventDeleted, post.ModerationStatus, "Deleted by author")
+	}
+
 	return c.SendStatus(http.StatusOK)
 }
 
 // PUT /api/v1/protected/posts/:id
 func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 	// 1. Auth Check
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -278,7 +440,7 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 	// 4. Parse Form Data (Manual parsing because of File Upload)
 	title := c.FormValue("title")
 	description := c.FormValue("description")
-	coordinates := c.FormValue("coordinates")
+	coordinates := strings.TrimSpace(c.FormValue("coordinates"))
 	ratingStr := c.FormValue("rating")
 
 	// Update fields if provided
@@ -289,7 +451,21 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 		oldPost.Description = description
 	}
 	if coordinates != "" {
+		lat, lng, err := utils.ParseCoordinates(coordinates)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		oldPost.Coordinates = coordinates
+		oldPost.Latitude = &lat
+		oldPost.Longitude = &lng
+	}
+
+	if countryValue := c.FormValue("country_code"); countryValue != "" {
+		countryCode, err := utils.ParseCountryCode(countryValue)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		oldPost.CountryCode = countryCode
 	}
 
 	if ratingStr != "" {
@@ -313,10 +489,41 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 	}
 	// If err != nil, it means no new file was sent. We keep oldPost.PhotoPath.
 
-	// 6. Save Updates (Repository forces verified = false)
+	// Drafts are submitted by sending draft=false; the same rules as Publish apply from then on.
+	// It only goes one way: a submitted post cannot be pulled back out of moderation into drafts.
+	wasDraft := oldPost.Draft
+	if draftValue := c.FormValue("draft"); draftValue != "" {
+		draft, err := parseDraftFlag(draftValue)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid draft value"})
+		}
+		if draft && !wasDraft {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "A submitted post cannot be turned back into a draft"})
+		}
+		oldPost.Draft = draft
+	}
+	if !oldPost.Draft && (oldPost.PhotoPath == "" || strings.TrimSpace(oldPost.Description) == "") {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "A photo and description are required before a draft can be submitted"})
+	}
+
+	// 6. Save Updates (Repository forces verified = false and re-queues the post)
+	previousStatus := oldPost.ModerationStatus
 	if err := h.repo.UpdatePost(ctx, oldPost); err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update post"})
 	}
+	oldPost.Verified = false
+	oldPost.ModerationStatus = core.ModerationPending
+	oldPost.RejectionReason = ""
+	oldPost.Entities = savePostEntities(ctx, h.entities, oldPost.ID, oldPost.Description)
+
+	switch {
+	case oldPost.Draft:
+		// Still a draft, so nothing entered the queue.
+	case wasDraft:
+		h.recordModerationEvent(ctx, oldPost, userID, core.EventCreated, "", "Draft submitted for review")
+	default:
+		h.recordModerationEvent(ctx, oldPost, userID, core.EventEdited, previousStatus, "Edited by author; re-queued for review")
+	}
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"message": "Post updated successfully",
@@ -324,59 +531,6 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 	})
 }
 
-// POST /api/v1/protected/posts/:id/comments
-func (h *PostHandler) AddComment(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
-	if err != nil {
-		return c.SendStatus(http.StatusUnauthorized)
-	}
-
-	postID, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Post ID"})
-	}
-
-	var req struct {
-		Content string `json:"content"`
-	}
-	if err := c.BodyParser(&req); err != nil || req.Content == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Content required"})
-	}
-
-	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
-	defer cancel()
-
-	comment := core.Comment{
-		PostID:  postID,
-		UserID:  userID,
-		Content: req.Content,
-	}
-
-	if err := h.repo.CreateComment(ctx, comment); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add comment"})
-	}
-
-	return c.Status(http.StatusCreated).JSON(fiber.Map{"message": "Comment added"})
-}
-
-// GET /api/v1/protected/posts/:id/comments
-func (h *PostHandler) GetComments(c *fiber.Ctx) error {
-	postID, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Post ID"})
-	}
-
-	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
-	defer cancel()
-
-	comments, err := h.repo.GetCommentsByPostID(ctx, postID)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch comments"})
-	}
-
-	return c.Status(http.StatusOK).JSON(comments)
-}
-
 // GET /api/v1/protected/users/photos/:filename
 func (h *ProfileHandler) GetUserProfilePhoto(c *fiber.Ctx) error {
 	filename := c.Params("filename")
@@ -396,7 +550,7 @@ func (h *ProfileHandler) GetUserProfilePhoto(c *fiber.Ctx) error {
 	return c.SendFile(filePath)
 }
 func (h *PostHandler) ToggleFavorite(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -408,6 +562,14 @@ func (h *PostHandler) ToggleFavorite(c *fiber.Ctx) error {
 
 	ctx := c.UserContext()
 
+	post, err := h.repo.GetPostByID(ctx, postID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+	if err != nil || !canViewPost(c, post, userID) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
 	// Check if currently favorite
 	isFav, err := h.repo.IsFavorite(ctx, userID, postID)
 	if err != nil {
@@ -419,19 +581,21 @@ func (h *PostHandler) ToggleFavorite(c *fiber.Ctx) error {
 		if err := h.repo.RemoveFavorite(ctx, userID, postID); err != nil {
 			return c.SendStatus(http.StatusInternalServerError)
 		}
+		h.notifier.RetractFromPostOwner(ctx, postID, userID, core.NotificationFavorite)
 		return c.JSON(fiber.Map{"is_favorite": false, "message": "Removed from favorites"})
 	} else {
 		// If NOT, add it
 		if err := h.repo.AddFavorite(ctx, userID, postID); err != nil {
 			return c.SendStatus(http.StatusInternalServerError)
 		}
+		h.notifier.NotifyPostOwner(ctx, postID, core.Notification{ActorID: &userID, Type: core.NotificationFavorite})
 		return c.JSON(fiber.Map{"is_favorite": true, "message": "Added to favorites"})
 	}
 }
 
 // GET /api/v1/protected/posts/:id/favorite
 func (h *PostHandler) CheckFavoriteStatus(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -451,7 +615,7 @@ func (h *PostHandler) CheckFavoriteStatus(c *fiber.Ctx) error {
 
 // GET /api/v1/protected/favorites
 func (h *PostHandler) GetUserFavorites(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -463,17 +627,33 @@ func (h *PostHandler) GetUserFavorites(c *fiber.Ctx) error {
 
 	return c.JSON(favs)
 }
+
+// canViewPost shows listed posts to everyone and the rest only to their author and moderators.
+func canViewPost(c *fiber.Ctx, post core.Post, viewerID uuid.UUID) bool {
+	return post.IsListed() || post.UserID == viewerID || isModerator(c)
+}
+
 func (h *PostHandler) GetUserPosts(c *fiber.Ctx) error {
 	targetUserID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
 	}
 
+	viewerID, _ := utils.ParseUUID(c.Locals("userID"))
+
 	posts, err := h.repo.GetPostsByUserID(c.UserContext(), targetUserID)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user posts"})
 	}
 
+	visible := make([]core.Post, 0, len(posts))
+	for _, p := range posts {
+		if canViewPost(c, p, viewerID) {
+			visible = append(visible, p)
+		}
+	}
+	posts = visible
+
 	return c.JSON(posts)
 }
 func (h *PostHandler) GetPosts(c *fiber.Ctx) error {
@@ -505,7 +685,7 @@ func (h *PostHandler) GetPosts(c *fiber.Ctx) error {
 }
 func (h *PostHandler) GetFeed(c *fiber.Ctx) error {
 	// 1. Get Current User ID (To exclude their own posts)
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -521,8 +701,16 @@ func (h *PostHandler) GetFeed(c *fiber.Ctx) error {
 	}
 	offset := (page - 1) * limit
 
-	// 3. Call the NEW Repository function
-	posts, err := h.repo.GetFeedPosts(c.UserContext(), limit, offset, userID)
+	// 3. "following" narrows the feed to people the user follows
+	var posts []core.Post
+	switch c.Query("mode", "all") {
+	case "all":
+		posts, err = h.repo.GetFeedPosts(c.UserContext(), limit, offset, userID)
+	case "following":
+		posts, err = h.repo.GetFollowingFeedPosts(c.UserContext(), limit, offset, userID)
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "mode must be one of all, following"})
+	}
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch feed"})
 	}
@@ -530,9 +718,50 @@ func (h *PostHandler) GetFeed(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(posts)
 }
 
+// GET /api/v1/protected/tags/:tag/posts?page=1&limit=10
+func (h *PostHandler) GetPostsByTag(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	// Non-ASCII tags such as #şelale arrive percent-encoded.
+	rawTag, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": utils.ErrInvalidHashtag.Error()})
+	}
+	tag, err := utils.NormalizeHashtag(rawTag)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	posts, err := h.repo.GetPostsByTag(ctx, tag, limit, (page-1)*limit, userID)
+	if err != nil {
+		fmt.Printf("get posts by tag: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch posts"})
+	}
+	if posts == nil {
+		posts = []core.Post{}
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"tag": tag, "posts": posts})
+}
+
 // POST /api/v1/protected/posts/:id/like
 func (h *PostHandler) ToggleLike(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -542,14 +771,36 @@ func (h *PostHandler) ToggleLike(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Post ID"})
 	}
 
-	if err := h.repo.ToggleLike(c.UserContext(), userID, postID); err != nil {
+	ctx := c.UserContext()
+
+	post, err := h.repo.GetPostByID(ctx, postID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+	if err != nil || !canViewPost(c, post, userID) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Post not found"})
+	}
+
+	liked, err := h.repo.ToggleLike(ctx, userID, postID)
+	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to toggle like"})
 	}
 
-	return c.SendStatus(http.StatusOK)
+	if liked {
+		h.notifier.NotifyPostOwner(ctx, postID, core.Notification{ActorID: &userID, Type: core.NotificationLike})
+	} else {
+		h.notifier.RetractFromPostOwner(ctx, postID, userID, core.NotificationLike)
+	}
+
+	if count, err := h.repo.GetLikeCount(postID); err == nil {
+		realtime.Publish(ctx, h.events, realtime.PostTopic(postID), realtime.EventPostLikeCount,
+			fiber.Map{"post_id": postID, "like_count": count})
+	}
+
+	return c.JSON(fiber.Map{"is_liked": liked})
 }
 func (h *PostHandler) CheckLikeStatus(c *fiber.Ctx) error {
-	userID, err := parseUserID(c.Locals("userID"))
+	userID, err := utils.ParseUUID(c.Locals("userID"))
 	if err != nil {
 		return c.SendStatus(http.StatusUnauthorized)
 	}
@@ -2,11 +2,16 @@ package private
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/TeamA166/WonderTrip/internal/core"
 	"github.com/TeamA166/WonderTrip/internal/repository"
@@ -16,11 +21,13 @@ import (
 )
 
 type ProfileHandler struct {
-	repo repository.UserRepository
+	repo     repository.UserRepository
+	sessions repository.SessionRepository
+	follows  repository.FollowRepository
 }
 
-func NewProfileHandler(repo repository.UserRepository) *ProfileHandler {
-	return &ProfileHandler{repo: repo}
+func NewProfileHandler(repo repository.UserRepository, sessions repository.SessionRepository, follows repository.FollowRepository) *ProfileHandler {
+	return &ProfileHandler{repo: repo, sessions: sessions, follows: follows}
 }
 
 func (h *ProfileHandler) GetProfilePhoto(c *fiber.Ctx) error {
@@ -57,16 +64,69 @@ func (h *ProfileHandler) GetProfile(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch profile"})
 	}
 
+	counts, err := h.follows.GetCounts(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch profile"})
+	}
+
 	// Map database model to JSON response
 	response := core.ProfileResponse{
-		ID:      user.ID.String(),
-		Email:   user.Email,
-		Name:    user.Name,
-		Surname: user.Surname,
+		ID:        user.ID.String(),
+		Email:     user.Email,
+		Name:      user.Name,
+		Surname:   user.Surname,
+		Username:  user.Username,
+		Bio:       user.Bio,
+		HomeCity:  user.HomeCity,
+		Website:   user.Website,
+		Gender:    user.Gender,
+		Followers: counts.Followers,
+		Following: counts.Following,
 	}
 
 	return c.Status(http.StatusOK).JSON(response)
 }
+
+// GET /api/v1/protected/users/:id
+func (h *ProfileHandler) GetPublicProfile(c *fiber.Ctx) error {
+	viewerID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	targetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	profile, err := h.repo.GetPublicProfile(ctx, targetID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		fmt.Printf("get public profile: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch profile"})
+	}
+
+	counts, err := h.follows.GetCounts(ctx, targetID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch profile"})
+	}
+	profile.Followers = counts.Followers
+	profile.Following = counts.Following
+
+	if viewerID != targetID {
+		if profile.IsFollowing, err = h.follows.IsFollowing(ctx, viewerID, targetID); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch profile"})
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(profile)
+}
+
 func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 
 	userID, err := utils.ParseUUID(c.Locals("userID"))
@@ -87,23 +147,100 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "User not found"})
 	}
 
-	if req.Name != "" {
-		user.Name = req.Name
-	}
-	if req.Surname != "" {
-		user.Surname = req.Surname
+	// Email changes must be confirmed from both addresses; see EmailChangeHandler.
+	if req.Email != nil && *req.Email != "" && strings.TrimSpace(strings.ToLower(*req.Email)) != user.Email {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Use /email-change to change your email address"})
 	}
-	if req.Email != "" {
-		user.Email = req.Email
+
+	if err := applyProfileUpdate(&user, req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if err := h.repo.UpdateUser(ctx, user); err != nil {
+	if err := h.repo.UpdateProfile(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrUsernameTaken) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update profile"})
 	}
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Profile updated successfully"})
 }
 
+const (
+	maxNameLength     = 100
+	maxBioLength      = 500
+	maxHomeCityLength = 100
+	maxWebsiteLength  = 255
+)
+
+// applyProfileUpdate validates every field present in req and copies it onto user.
+// Nothing is changed if any field is invalid.
+func applyProfileUpdate(user *core.User, req core.UpdateProfileRequest) error {
+	updated := *user
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return fmt.Errorf("Name must be between 1 and %d characters", maxNameLength)
+		}
+		updated.Name = name
+	}
+	if req.Surname != nil {
+		surname := strings.TrimSpace(*req.Surname)
+		if surname == "" || utf8.RuneCountInString(surname) > maxNameLength {
+			return fmt.Errorf("Surname must be between 1 and %d characters", maxNameLength)
+		}
+		updated.Surname = surname
+	}
+	if req.Username != nil {
+		username, err := utils.NormalizeUsername(*req.Username)
+		if err != nil {
+			return err
+		}
+		updated.Username = username
+	}
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return fmt.Errorf("Bio must be at most %d characters", maxBioLength)
+		}
+		updated.Bio = bio
+	}
+	if req.HomeCity != nil {
+		city := strings.TrimSpace(*req.HomeCity)
+		if utf8.RuneCountInString(city) > maxHomeCityLength {
+			return fmt.Errorf("Home city must be at most %d characters", maxHomeCityLength)
+		}
+		updated.HomeCity = city
+	}
+	if req.Website != nil {
+		website := strings.TrimSpace(*req.Website)
+		if website != "" {
+			if len(website) > maxWebsiteLength {
+				return fmt.Errorf("Website must be at most %d characters", maxWebsiteLength)
+			}
+			u, err := url.Parse(website)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+				return errors.New("Website must be a valid http or https URL")
+			}
+		}
+		updated.Website = website
+	}
+	if req.Gender != nil {
+		gender := strings.ToLower(strings.TrimSpace(*req.Gender))
+		if gender == "" {
+			gender = core.GenderNotSet
+		}
+		if !core.IsValidGender(gender) {
+			return errors.New("gender must be one of male, female, prefer_not_to_say, not_set")
+		}
+		updated.Gender = gender
+	}
+
+	*user = updated
+	return nil
+}
+
 // POST /api/v1/protected/profile-photo
 func (h *ProfileHandler) UploadProfilePhoto(c *fiber.Ctx) error {
 	// 1. Get User ID
@@ -185,5 +322,10 @@ func (h *ProfileHandler) ChangePassword(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Database update failed"})
 	}
 
+	// 7. Log out every other device; the one that changed the password stays signed in
+	if _, err := h.sessions.RevokeOtherSessions(ctx, userID, currentSessionID(c), "password_change"); err != nil {
+		fmt.Printf("revoke sessions after password change: %v\n", err)
+	}
+
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Password updated successfully"})
 }
@@ -0,0 +1,110 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RoleHandler struct {
+	repo repository.RoleRepository
+}
+
+func NewRoleHandler(repo repository.RoleRepository) *RoleHandler {
+	return &RoleHandler{repo: repo}
+}
+
+// PUT /api/v1/admin/users/:id/role
+func (h *RoleHandler) UpdateUserRole(c *fiber.Ctx) error {
+	adminID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	targetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	// Otherwise the last admin could lock everyone out of role management.
+	if targetID == adminID {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "You cannot change your own role"})
+	}
+
+	var req core.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	req.Role = strings.ToLower(strings.TrimSpace(req.Role))
+	req.Reason = strings.TrimSpace(req.Reason)
+	if !core.IsValidRole(req.Role) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "role must be one of user, moderator, admin"})
+	}
+	if req.Reason == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "A reason is required for role changes"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	change, err := h.repo.ChangeRole(ctx, targetID, req.Role, adminID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		case errors.Is(err, repository.ErrRoleUnchanged):
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		fmt.Printf("change role: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to change role"})
+	}
+
+	return c.Status(http.StatusOK).JSON(change)
+}
+
+// GET /api/v1/admin/users/:id/role-history
+func (h *RoleHandler) GetRoleHistory(c *fiber.Ctx) error {
+	targetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid User ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	history, err := h.repo.GetRoleHistory(ctx, targetID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch role history"})
+	}
+
+	return c.Status(http.StatusOK).JSON(history)
+}
+
+// GET /api/v1/admin/users?role=moderator
+func (h *RoleHandler) GetUsersByRole(c *fiber.Ctx) error {
+	role := strings.ToLower(c.Query("role", core.RoleModerator))
+	if !core.IsValidRole(role) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "role must be one of user, moderator, admin"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	users, err := h.repo.GetUsersByRole(ctx, role)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch users"})
+	}
+
+	return c.Status(http.StatusOK).JSON(users)
+}
@@ -0,0 +1,100 @@
+package private
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	repo repository.SessionRepository
+}
+
+func NewSessionHandler(repo repository.SessionRepository) *SessionHandler {
+	return &SessionHandler{repo: repo}
+}
+
+// currentSessionID reads the "sid" claim of the access token, or uuid.Nil if it cannot be parsed.
+func currentSessionID(c *fiber.Ctx) uuid.UUID {
+	sid, err := utils.ParseUUID(c.Locals("sessionID"))
+	if err != nil {
+		return uuid.Nil
+	}
+	return sid
+}
+
+// GET /api/v1/protected/sessions
+func (h *SessionHandler) ListSessions(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := h.repo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch sessions"})
+	}
+
+	current := currentSessionID(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	return c.Status(http.StatusOK).JSON(sessions)
+}
+
+// DELETE /api/v1/protected/sessions/:id
+func (h *SessionHandler) RevokeSession(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Session ID"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if err := h.repo.RevokeSession(ctx, userID, sessionID, "user_revoked"); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke session"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Session revoked"})
+}
+
+// DELETE /api/v1/protected/sessions
+// Logs out every other device and keeps the session making this request.
+func (h *SessionHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	userID, err := utils.ParseUUID(c.Locals("userID"))
+	if err != nil {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	revoked, err := h.repo.RevokeOtherSessions(ctx, userID, currentSessionID(c), "user_revoked_others")
+	if err != nil {
+		fmt.Printf("revoke other sessions: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke sessions"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"revoked": revoked})
+}
@@ -9,38 +9,62 @@ import (
 	"strings"
 	"time"
 
+	"github.com/TeamA166/WonderTrip/internal/api/throttle"
 	"github.com/TeamA166/WonderTrip/internal/core"
 	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
+	"github.com/TeamA166/WonderTrip/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const refreshCookiePath = "/api/v1/auth"
+
 type AuthHandler struct {
-	repo         repository.UserRepository
-	tokenSecret  string
-	tokenExpiry  time.Duration
-	passwordCost int
+	repo          repository.UserRepository
+	sessions      repository.SessionRepository
+	verifications repository.EmailVerificationRepository
+	mfa           repository.MFARepository
+	accounts      repository.AccountRepository
+	loginGuard    throttle.Guard
+	mfaGuard      throttle.Guard
+	signer        *tokens.Signer
+	tokenExpiry   time.Duration
+	refreshExpiry time.Duration
+	passwordCost  int
 }
 
-func NewAuthHandler(repo repository.UserRepository, tokenSecret string, tokenExpiry time.Duration, passwordCost int) (*AuthHandler, error) {
-	if tokenSecret == "" {
-		return nil, errors.New("auth handler: token secret is required")
+func NewAuthHandler(repo repository.UserRepository, sessions repository.SessionRepository, verifications repository.EmailVerificationRepository, mfa repository.MFARepository, accounts repository.AccountRepository, attempts repository.LoginAttemptRepository, signer *tokens.Signer, tokenExpiry, refreshExpiry time.Duration, passwordCost int) (*AuthHandler, error) {
+	if signer == nil {
+		return nil, errors.New("auth handler: token signer is required")
 	}
 
 	if tokenExpiry <= 0 {
 		tokenExpiry = 15 * time.Minute
 	}
 
+	if refreshExpiry <= 0 {
+		refreshExpiry = 30 * 24 * time.Hour
+	}
+
 	if passwordCost < bcrypt.MinCost || passwordCost > bcrypt.MaxCost {
 		passwordCost = bcrypt.DefaultCost
 	}
 
 	return &AuthHandler{
-		repo:         repo,
-		tokenSecret:  tokenSecret,
-		tokenExpiry:  tokenExpiry,
-		passwordCost: passwordCost,
+		repo:          repo,
+		sessions:      sessions,
+		verifications: verifications,
+		mfa:           mfa,
+		accounts:      accounts,
+		loginGuard:    throttle.NewGuard(attempts, "login"),
+		mfaGuard:      throttle.NewGuard(attempts, "mfa"),
+		signer:        signer,
+		tokenExpiry:   tokenExpiry,
+		refreshExpiry: refreshExpiry,
+		passwordCost:  passwordCost,
 	}, nil
 }
 
@@ -64,7 +88,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	if _, err := h.repo.GetByEmail(ctx, req.Email); err == nil {
 		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Bu e-posta zaten kayıtlı"})
 	} else if !errors.Is(err, sql.ErrNoRows) {
-		fmt.Printf(err.Error())
+		fmt.Println(err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Kullanıcı kontrolü başarısız"})
 
 	}
@@ -83,12 +107,18 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 
 	created, err := h.repo.CreateUser(ctx, user)
 	if err != nil {
-		fmt.Printf(err.Error())
+		fmt.Println(err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Kullanıcı kaydedilemedi"})
 	}
 
-	authResponse, err := h.buildAuthResponse(created)
+	// The account exists either way; a failed email can be retried via resend-verification.
+	if err := sendVerificationCode(ctx, h.verifications, created.ID, created.Email); err != nil {
+		fmt.Println(err)
+	}
+
+	authResponse, err := h.startSession(ctx, c, created)
 	if err != nil {
+		fmt.Println(err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Token oluşturulamadı"})
 	}
 
@@ -113,31 +143,51 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
+	if wait := h.loginGuard.LockedFor(ctx, req.Email, c.IP()); wait > 0 {
+		return throttle.TooManyAttempts(c, wait)
+	}
+
 	user, err := h.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "E-posta veya şifre hatalı"})
+			return h.loginFailed(ctx, c, req.Email)
 		}
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Kullanıcı getirilemedi"})
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "E-posta veya şifre hatalı"})
+		return h.loginFailed(ctx, c, req.Email)
 	}
+	h.loginGuard.Succeed(ctx, req.Email)
 
-	authResponse, err := h.buildAuthResponse(user)
+	return h.completeLogin(ctx, c, user)
+}
+
+// completeLogin runs once the user has proven who they are (password or an external identity).
+// With 2FA on it only hands out a short-lived token for /login/mfa.
+func (h *AuthHandler) completeLogin(ctx context.Context, c *fiber.Ctx, user core.User) error {
+	if mfa, err := h.mfa.Get(ctx, user.ID); err == nil && mfa.Enabled() {
+		mfaToken, err := h.issueMFAToken(user.ID)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Token oluşturulamadı"})
+		}
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int64(mfaTokenTTL.Seconds()),
+		})
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Println(err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Kullanıcı getirilemedi"})
+	}
+
+	authResponse, err := h.startSession(ctx, c, user)
 	if err != nil {
+		fmt.Println(err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Token oluşturulamadı"})
 	}
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "token",
-		Value:    authResponse.AccessToken,
-		Expires:  time.Now().Add(h.tokenExpiry),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Strict",
-	})
+	h.setAuthCookies(c, authResponse)
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"user": sanitizeUser(user),
@@ -145,19 +195,107 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	})
 }
 
-func (h *AuthHandler) buildAuthResponse(user core.User) (core.AuthResponse, error) {
+// loginFailed counts unknown emails too, so lockouts do not reveal which accounts exist.
+func (h *AuthHandler) loginFailed(ctx context.Context, c *fiber.Ctx, email string) error {
+	if wait := h.loginGuard.Fail(ctx, email, c.IP()); wait > 0 {
+		return throttle.TooManyAttempts(c, wait)
+	}
+	return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "E-posta veya şifre hatalı"})
+}
+
+// POST /api/v1/auth/refresh
+// Exchanges a refresh token (body or cookie) for a new access token and a new refresh token.
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	refreshToken := h.refreshTokenFromRequest(c)
+	if refreshToken == "" {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Refresh token is required"})
+	}
+
+	newToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Token oluşturulamadı"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	session, err := h.sessions.RotateRefreshToken(ctx, utils.HashToken(refreshToken), utils.HashToken(newToken), h.refreshExpiry)
+	if err != nil {
+		h.clearAuthCookies(c)
+		switch {
+		case errors.Is(err, repository.ErrRefreshTokenReused):
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Refresh token was already used; this session has been revoked. Please log in again."})
+		case errors.Is(err, repository.ErrRefreshTokenInvalid):
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Refresh token is invalid or expired"})
+		}
+		fmt.Println(err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Token yenilenemedi"})
+	}
+
+	// Re-read the user so a changed email or role is reflected in the new access token.
+	user, err := h.repo.GetById(ctx, session.UserID)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Refresh token is invalid or expired"})
+	}
+
+	authResponse, err := h.buildAuthResponse(user, session.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Token oluşturulamadı"})
+	}
+	authResponse.RefreshToken = newToken
+	authResponse.RefreshExpiresIn = int64(h.refreshExpiry.Seconds())
+
+	h.setAuthCookies(c, authResponse)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"auth": authResponse})
+}
+
+// startSession persists a new login and returns both tokens for it.
+func (h *AuthHandler) startSession(ctx context.Context, c *fiber.Ctx, user core.User) (core.AuthResponse, error) {
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return core.AuthResponse{}, fmt.Errorf("refresh token oluşturulamadı: %w", err)
+	}
+
+	session, err := h.sessions.CreateSession(ctx, core.Session{
+		UserID:    user.ID,
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IPAddress: c.IP(),
+		ExpiresAt: time.Now().Add(h.refreshExpiry),
+	}, utils.HashToken(refreshToken))
+	if err != nil {
+		return core.AuthResponse{}, err
+	}
+
+	// Signing in is how a user takes back a pending account deletion.
+	if cancelled, err := h.accounts.CancelDeletion(ctx, user.ID); err != nil {
+		fmt.Println(err)
+	} else if cancelled {
+		fmt.Printf("account deletion cancelled by login: %s\n", user.ID)
+	}
+
+	authResponse, err := h.buildAuthResponse(user, session.ID)
+	if err != nil {
+		return core.AuthResponse{}, err
+	}
+	authResponse.RefreshToken = refreshToken
+	authResponse.RefreshExpiresIn = int64(h.refreshExpiry.Seconds())
+
+	return authResponse, nil
+}
+
+func (h *AuthHandler) buildAuthResponse(user core.User, sessionID uuid.UUID) (core.AuthResponse, error) {
 	expiresAt := time.Now().Add(h.tokenExpiry)
 	issuedAt := time.Now()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	signed, err := h.signer.Sign(tokens.TypeAccess, jwt.MapClaims{
 		"sub":   user.ID,
+		"sid":   sessionID,
 		"email": user.Email,
 		"role":  user.Role,
 		"exp":   expiresAt.Unix(),
 		"iat":   issuedAt.Unix(),
 	})
-
-	signed, err := token.SignedString([]byte(h.tokenSecret))
 	if err != nil {
 		return core.AuthResponse{}, fmt.Errorf("token imzalanamadı: %w", err)
 	}
@@ -174,11 +312,12 @@ func sanitizeEmail(email string) string {
 
 func sanitizeUser(user core.User) fiber.Map {
 	return fiber.Map{
-		"id":        user.ID,
-		"email":     user.Email,
-		"name":      user.Name,
-		"createdAt": user.CreatedAt,
-		"updatedAt": user.UpdatedAt,
+		"id":            user.ID,
+		"email":         user.Email,
+		"name":          user.Name,
+		"emailVerified": user.EmailVerifiedAt != nil,
+		"createdAt":     user.CreatedAt,
+		"updatedAt":     user.UpdatedAt,
 	}
 }
 
@@ -199,7 +338,89 @@ func validateRegisterRequest(req core.RegisterRequest) error {
 }
 
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	// Clear the cookie by setting an expired date
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	// Revoke the session behind the refresh token so it can no longer mint access tokens.
+	if refreshToken := h.refreshTokenFromRequest(c); refreshToken != "" {
+		if err := h.sessions.RevokeByTokenHash(ctx, utils.HashToken(refreshToken), "logout"); err != nil {
+			fmt.Println(err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Çıkış yapılamadı"})
+		}
+	}
+
+	// Clients that only hold an access token log out its session, which also stops the token itself.
+	if userID, sessionID, ok := h.accessSessionFromRequest(c); ok {
+		if err := h.sessions.RevokeSession(ctx, userID, sessionID, "logout"); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			fmt.Println(err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Çıkış yapılamadı"})
+		}
+	}
+
+	h.clearAuthCookies(c)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Successfully logged out",
+	})
+}
+
+// accessSessionFromRequest reads the session from a valid access token, sent the same ways
+// the auth middleware accepts it.
+func (h *AuthHandler) accessSessionFromRequest(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool) {
+	tokenString := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	if tokenString == "" {
+		tokenString = c.Cookies("token")
+	}
+	if tokenString == "" {
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	claims, err := h.signer.Parse(tokenString, tokens.TypeAccess)
+	if err != nil {
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+	userID, err := uuid.Parse(fmt.Sprint(claims["sub"]))
+	if err != nil {
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+	sessionID, err := uuid.Parse(fmt.Sprint(claims["sid"]))
+	if err != nil {
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+	return userID, sessionID, true
+}
+
+func (h *AuthHandler) refreshTokenFromRequest(c *fiber.Ctx) string {
+	var req core.RefreshRequest
+	_ = c.BodyParser(&req)
+	if token := strings.TrimSpace(req.RefreshToken); token != "" {
+		return token
+	}
+	return c.Cookies("refresh_token")
+}
+
+func (h *AuthHandler) setAuthCookies(c *fiber.Ctx, auth core.AuthResponse) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "token",
+		Value:    auth.AccessToken,
+		Expires:  time.Now().Add(h.tokenExpiry),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Strict",
+	})
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    auth.RefreshToken,
+		Path:     refreshCookiePath,
+		Expires:  time.Now().Add(h.refreshExpiry),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Strict",
+	})
+}
+
+func (h *AuthHandler) clearAuthCookies(c *fiber.Ctx) {
+	// Clear the cookies by setting an expired date
 	c.Cookie(&fiber.Cookie{
 		Name:     "token",
 		Value:    "",
@@ -208,9 +429,14 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 		Secure:   true,
 		SameSite: "Strict",
 	})
-
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Successfully logged out",
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     refreshCookiePath,
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Strict",
 	})
 }
 func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
@@ -0,0 +1,110 @@
+package public
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type EmailChangeRevertHandler struct {
+	repo     repository.EmailChangeRepository
+	userRepo repository.UserRepository
+	sessions repository.SessionRepository
+}
+
+func NewEmailChangeRevertHandler(repo repository.EmailChangeRepository, userRepo repository.UserRepository, sessions repository.SessionRepository) *EmailChangeRevertHandler {
+	return &EmailChangeRevertHandler{repo: repo, userRepo: userRepo, sessions: sessions}
+}
+
+// revertConfirmPage is served for the link in the email. Opening it changes nothing, so
+// link scanners and prefetchers cannot trigger the revert; the button posts the token back.
+var revertConfirmPage = template.Must(template.New("revert").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>WonderTrip - Restore your email</title></head>
+<body>
+<h1>Restore your previous email address?</h1>
+<p>This puts your previous address back on your WonderTrip account and signs out every device.</p>
+<form method="POST" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Restore my email address</button>
+</form>
+</body>
+</html>`))
+
+// GET /api/v1/auth/email-change/revert?token=...
+// Landing page for the link mailed to the previous address; it only asks for confirmation.
+func (h *EmailChangeRevertHandler) ShowRevertEmailChange(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(http.StatusBadRequest).SendString("Invalid or expired link")
+	}
+
+	var page bytes.Buffer
+	data := struct{ Action, Token string }{Action: c.Path(), Token: token}
+	if err := revertConfirmPage.Execute(&page, data); err != nil {
+		return c.Status(http.StatusInternalServerError).SendString("Server error")
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set("Referrer-Policy", "no-referrer")
+	return c.Status(http.StatusOK).Send(page.Bytes())
+}
+
+// POST /api/v1/auth/email-change/revert
+// Takes the token from the confirmation form (or a JSON body), restores the previous
+// address and signs the account out everywhere.
+func (h *EmailChangeRevertHandler) RevertEmailChange(c *fiber.Ctx) error {
+	var req struct {
+		Token string `json:"token" form:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired link"})
+	}
+	token := req.Token
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	tokenHash := utils.HashToken(token)
+	change, err := h.repo.GetByRevertToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired link"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	if change.RevertExpiresAt == nil || time.Now().After(*change.RevertExpiresAt) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired link"})
+	}
+
+	if owner, err := h.userRepo.GetByEmail(ctx, change.OldEmail); err == nil && owner.ID != change.UserID {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Your previous address is now used by another account. Please contact support."})
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	if err := h.repo.Revert(ctx, change.UserID, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired link"})
+		}
+		fmt.Printf("revert email change: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	// Whoever changed the address may still be signed in.
+	if _, err := h.sessions.RevokeOtherSessions(ctx, change.UserID, uuid.Nil, "email_change_reverted"); err != nil {
+		fmt.Printf("revoke sessions after email revert: %v\n", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Your previous email address was restored and all devices were signed out. Please reset your password."})
+}
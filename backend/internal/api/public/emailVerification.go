@@ -0,0 +1,154 @@
+package public
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/api/throttle"
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	verificationCodeTTL         = 24 * time.Hour
+	verificationResendWait      = time.Minute
+	maxVerificationCodeAttempts = 5
+)
+
+type EmailVerificationHandler struct {
+	repo        repository.EmailVerificationRepository
+	userRepo    repository.UserRepository
+	verifyGuard throttle.Guard
+}
+
+func NewEmailVerificationHandler(repo repository.EmailVerificationRepository, userRepo repository.UserRepository, attempts repository.LoginAttemptRepository) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		repo:        repo,
+		userRepo:    userRepo,
+		verifyGuard: throttle.NewGuard(attempts, "verify_email"),
+	}
+}
+
+// sendVerificationCode stores a fresh code for the user and mails it in the background.
+func sendVerificationCode(ctx context.Context, repo repository.EmailVerificationRepository, userID uuid.UUID, email string) error {
+	otp, err := utils.GenerateOTP()
+	if err != nil {
+		return err
+	}
+	otpHash, err := utils.HashCode(otp)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.SaveCode(ctx, userID, otpHash, time.Now().Add(verificationCodeTTL)); err != nil {
+		return err
+	}
+
+	go func(targetEmail, code string) {
+		subject := "WonderTrip - Verify your email"
+		body := fmt.Sprintf("Hi,\n\nWelcome to WonderTrip! Your verification code: %s\n\nThis code will be expired after 24 hours.", code)
+
+		if err := utils.SendEmail(targetEmail, subject, body); err != nil {
+			fmt.Printf("Email error for %s: %v\n", targetEmail, err)
+		}
+	}(email, otp)
+
+	return nil
+}
+
+// POST /api/v1/auth/verify-email
+// Unknown emails, verified accounts, expired and wrong codes all get the same answer
+// so the endpoint cannot be used to probe for accounts.
+func (h *EmailVerificationHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req core.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	req.Email = sanitizeEmail(req.Email)
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if wait := h.verifyGuard.LockedFor(ctx, req.Email, c.IP()); wait > 0 {
+		return throttle.TooManyAttempts(c, wait)
+	}
+
+	invalidCode := func() error {
+		if wait := h.verifyGuard.Fail(ctx, req.Email, c.IP()); wait > 0 {
+			return throttle.TooManyAttempts(c, wait)
+		}
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired code"})
+	}
+
+	user, err := h.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || user.EmailVerifiedAt != nil {
+		return invalidCode()
+	}
+
+	// The attempt is counted before the code is checked; a burned code answers like a missing one.
+	verification, err := h.repo.ClaimAttempt(ctx, user.ID, maxVerificationCodeAttempts)
+	if err != nil || time.Now().After(verification.ExpiresAt) {
+		return invalidCode()
+	}
+
+	if !utils.VerifyHash(verification.CodeHash, req.Code) {
+		// The code itself is burned after a few misses, independent of the lockout.
+		if verification.Attempts >= maxVerificationCodeAttempts {
+			if err := h.repo.DeleteCode(ctx, user.ID); err != nil {
+				fmt.Printf("delete verification code: %v\n", err)
+			}
+		}
+		return invalidCode()
+	}
+	h.verifyGuard.Succeed(ctx, req.Email)
+
+	if err := h.repo.MarkVerified(ctx, user.ID); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Email verified successfully"})
+}
+
+// POST /api/v1/auth/resend-verification
+// Always answers the same way so the endpoint cannot be used to probe for accounts.
+func (h *EmailVerificationHandler) ResendVerification(c *fiber.Ctx) error {
+	var req core.ResendVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	req.Email = sanitizeEmail(req.Email)
+
+	const genericMessage = "If this email belongs to an unverified account we sent a new code."
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusOK).JSON(fiber.Map{"message": genericMessage})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server Error."})
+	}
+	if user.EmailVerifiedAt != nil {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"message": genericMessage})
+	}
+
+	if existing, err := h.repo.GetByUserID(ctx, user.ID); err == nil && time.Since(existing.CreatedAt) < verificationResendWait {
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{"error": "Please wait a minute before requesting another code"})
+	}
+
+	if err := sendVerificationCode(ctx, h.repo, user.ID, user.Email); err != nil {
+		fmt.Printf("resend verification: %v\n", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Kod oluşturulamadı"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": genericMessage})
+}
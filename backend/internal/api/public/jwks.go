@@ -0,0 +1,23 @@
+package public
+
+import (
+	"net/http"
+
+	"github.com/TeamA166/WonderTrip/internal/tokens"
+	"github.com/gofiber/fiber/v2"
+)
+
+type JWKSHandler struct {
+	signer *tokens.Signer
+}
+
+func NewJWKSHandler(signer *tokens.Signer) *JWKSHandler {
+	return &JWKSHandler{signer: signer}
+}
+
+// GET /.well-known/jwks.json
+// Lets other services verify our access tokens. Cached briefly so rotated keys show up quickly.
+func (h *JWKSHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(http.StatusOK).JSON(h.signer.JWKS())
+}
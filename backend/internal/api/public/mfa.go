@@ -0,0 +1,122 @@
+package public
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/api/throttle"
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const mfaTokenTTL = 5 * time.Minute
+
+// POST /api/v1/auth/login/mfa
+// Second step of a login that answered with mfa_required.
+func (h *AuthHandler) LoginMFA(c *fiber.Ctx) error {
+	var req core.MFALoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Geçersiz istek gövdesi"})
+	}
+
+	userID, err := h.parseMFAToken(req.MFAToken)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Login expired. Please log in again."})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	account := userID.String()
+	if wait := h.mfaGuard.LockedFor(ctx, account, c.IP()); wait > 0 {
+		return throttle.TooManyAttempts(c, wait)
+	}
+
+	ok, err := h.verifySecondFactor(ctx, userID, req)
+	if err != nil {
+		fmt.Println(err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	if !ok {
+		if wait := h.mfaGuard.Fail(ctx, account, c.IP()); wait > 0 {
+			return throttle.TooManyAttempts(c, wait)
+		}
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Incorrect code"})
+	}
+	h.mfaGuard.Succeed(ctx, account)
+
+	user, err := h.repo.GetById(ctx, userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Kullanıcı getirilemedi"})
+	}
+
+	authResponse, err := h.startSession(ctx, c, user)
+	if err != nil {
+		fmt.Println(err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Token oluşturulamadı"})
+	}
+
+	h.setAuthCookies(c, authResponse)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"user": sanitizeUser(user),
+		"auth": authResponse,
+	})
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code. Both are single use.
+func (h *AuthHandler) verifySecondFactor(ctx context.Context, userID uuid.UUID, req core.MFALoginRequest) (bool, error) {
+	mfa, err := h.mfa.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !mfa.Enabled() {
+		return false, nil
+	}
+
+	if req.Code != "" {
+		step, ok := utils.ValidateTOTP(mfa.Secret, req.Code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return h.mfa.MarkStepUsed(ctx, userID, step)
+	}
+
+	code := utils.NormalizeRecoveryCode(req.RecoveryCode)
+	if code == "" {
+		return false, nil
+	}
+	candidates, err := h.mfa.GetRecoveryCodes(ctx, userID, utils.RecoveryCodePrefix(code))
+	if err != nil {
+		return false, err
+	}
+	for _, candidate := range candidates {
+		if utils.VerifyHash(candidate.CodeHash, code) {
+			return h.mfa.UseRecoveryCode(ctx, candidate.ID)
+		}
+	}
+	return false, nil
+}
+
+func (h *AuthHandler) issueMFAToken(userID uuid.UUID) (string, error) {
+	now := time.Now()
+	return h.signer.Sign(tokens.TypeMFAPending, jwt.MapClaims{
+		"sub": userID,
+		"exp": now.Add(mfaTokenTTL).Unix(),
+		"iat": now.Unix(),
+	})
+}
+
+func (h *AuthHandler) parseMFAToken(tokenString string) (uuid.UUID, error) {
+	claims, err := h.signer.Parse(tokenString, tokens.TypeMFAPending)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return utils.ParseUUID(claims["sub"])
+}
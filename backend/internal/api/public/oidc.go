@@ -0,0 +1,297 @@
+package public
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/config"
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/oidc"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	oidcStateTTL = 10 * time.Minute
+
+	// The state is also kept in a cookie so a callback only completes in the browser that started
+	// the login; otherwise a victim could be logged into the attacker's account (login CSRF).
+	oidcStateCookie     = "oidc_state"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+)
+
+type oidcProvider struct {
+	client      *oidc.Provider
+	redirectURL string
+}
+
+// OIDCHandler signs users in with external OpenID Connect providers and then
+// finishes the login exactly like a password login, including 2FA.
+type OIDCHandler struct {
+	auth      *AuthHandler
+	repo      repository.OIDCRepository
+	providers map[string]oidcProvider
+}
+
+func NewOIDCHandler(auth *AuthHandler, repo repository.OIDCRepository, providers map[string]config.OIDCProvider) *OIDCHandler {
+	h := &OIDCHandler{auth: auth, repo: repo, providers: make(map[string]oidcProvider, len(providers))}
+	for name, p := range providers {
+		h.providers[name] = oidcProvider{
+			client: oidc.NewProvider(name, oidc.Config{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				Scopes:       p.Scopes,
+			}),
+			redirectURL: p.RedirectURL,
+		}
+	}
+	return h
+}
+
+// GET /api/v1/auth/oidc/:provider/login
+// Redirects the browser to the provider's consent screen.
+func (h *OIDCHandler) Login(c *fiber.Ctx) error {
+	name := c.Params("provider")
+	provider, ok := h.providers[name]
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Unknown login provider"})
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+	verifier, err := oidc.NewPKCEVerifier()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	redirectURL := provider.redirectURL
+	if redirectURL == "" {
+		redirectURL = c.BaseURL() + "/api/v1/auth/oidc/" + name + "/callback"
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	authURL, err := provider.client.AuthCodeURL(ctx, redirectURL, state, nonce, verifier)
+	if err != nil {
+		fmt.Printf("oidc %s: %v\n", name, err)
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{"error": "Login provider is unavailable"})
+	}
+
+	err = h.repo.SaveLoginState(ctx, core.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     name,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		RedirectURL:  redirectURL,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		fmt.Printf("oidc %s: %v\n", name, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcStateCookiePath,
+		Expires:  time.Now().Add(oidcStateTTL),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax", // Lax so the cookie survives the top-level redirect back from the provider
+	})
+
+	return c.Redirect(authURL, http.StatusFound)
+}
+
+// GET /api/v1/auth/oidc/:provider/callback
+func (h *OIDCHandler) Callback(c *fiber.Ctx) error {
+	name := c.Params("provider")
+	provider, ok := h.providers[name]
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Unknown login provider"})
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Login was cancelled or denied", "provider_error": providerErr})
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Missing code or state"})
+	}
+
+	cookieState := c.Cookies(oidcStateCookie)
+	h.clearStateCookie(c)
+	if subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Login expired. Please try again."})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
+
+	loginState, err := h.repo.ConsumeLoginState(ctx, utils.HashToken(state))
+	if err != nil || loginState.Provider != name || time.Now().After(loginState.ExpiresAt) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Login expired. Please try again."})
+	}
+
+	claims, err := provider.client.Exchange(ctx, code, loginState.RedirectURL, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		fmt.Printf("oidc %s: %v\n", name, err)
+		if errors.Is(err, oidc.ErrInvalidIDToken) {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Login provider returned an invalid token"})
+		}
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{"error": "Login provider is unavailable"})
+	}
+
+	user, status, err := h.resolveUser(ctx, name, claims)
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			fmt.Printf("oidc %s: %v\n", name, err)
+			return c.Status(status).JSON(fiber.Map{"error": "Server error"})
+		}
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return h.auth.completeLogin(ctx, c, user)
+}
+
+func (h *OIDCHandler) clearStateCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Path:     oidcStateCookiePath,
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax",
+	})
+}
+
+// resolveUser finds the account behind an external identity, linking or creating one on first login.
+// An existing password account is only linked when the provider vouches for the email address.
+func (h *OIDCHandler) resolveUser(ctx context.Context, provider string, claims oidc.Claims) (core.User, int, error) {
+	userID, err := h.repo.GetUserIDByIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		user, err := h.auth.repo.GetById(ctx, userID)
+		if err != nil {
+			return core.User{}, http.StatusInternalServerError, err
+		}
+		return user, http.StatusOK, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return core.User{}, http.StatusInternalServerError, err
+	}
+
+	email := sanitizeEmail(claims.Email)
+	if email == "" {
+		return core.User{}, http.StatusBadRequest, errors.New("The login provider did not share an email address")
+	}
+
+	user, err := h.auth.repo.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !claims.EmailVerified {
+			return core.User{}, http.StatusConflict, errors.New("An account with this email already exists. Please log in with your password.")
+		}
+		if user.EmailVerifiedAt == nil {
+			// Nobody ever proved they own this address locally, so whoever registered it may not be
+			// the person now signing in. Take the account over from them before linking.
+			if err := h.claimUnverifiedAccount(ctx, user.ID); err != nil {
+				return core.User{}, http.StatusInternalServerError, err
+			}
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		user, err = h.createUser(ctx, email, claims)
+		if err != nil {
+			return core.User{}, http.StatusInternalServerError, err
+		}
+	default:
+		return core.User{}, http.StatusInternalServerError, err
+	}
+
+	if err := h.repo.LinkIdentity(ctx, core.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    email,
+	}); err != nil {
+		return core.User{}, http.StatusInternalServerError, err
+	}
+	return user, http.StatusOK, nil
+}
+
+// claimUnverifiedAccount prepares an unverified password account for linking: the password set by
+// whoever registered it stops working, their sessions are revoked and the email counts as verified.
+func (h *OIDCHandler) claimUnverifiedAccount(ctx context.Context, userID uuid.UUID) error {
+	hash, err := h.unusablePasswordHash()
+	if err != nil {
+		return err
+	}
+	return h.repo.ClaimUnverifiedAccount(ctx, userID, hash)
+}
+
+// unusablePasswordHash hashes a random secret nobody knows; the user can set a real password via forgot-password.
+func (h *OIDCHandler) unusablePasswordHash() (string, error) {
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), h.auth.passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// createUser registers an account without a usable password; the user can set one via forgot-password.
+func (h *OIDCHandler) createUser(ctx context.Context, email string, claims oidc.Claims) (core.User, error) {
+	hash, err := h.unusablePasswordHash()
+	if err != nil {
+		return core.User{}, err
+	}
+
+	name, surname := strings.TrimSpace(claims.GivenName), strings.TrimSpace(claims.FamilyName)
+	if name == "" && surname == "" {
+		name, surname, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+
+	created, err := h.auth.repo.CreateUser(ctx, core.User{
+		Email:        email,
+		Name:         name,
+		Surname:      strings.TrimSpace(surname),
+		PasswordHash: hash,
+	})
+	if err != nil {
+		return core.User{}, err
+	}
+
+	if claims.EmailVerified {
+		if err := h.auth.verifications.MarkVerified(ctx, created.ID); err != nil {
+			return core.User{}, err
+		}
+		now := time.Now()
+		created.EmailVerifiedAt = &now
+	} else if err := sendVerificationCode(ctx, h.auth.verifications, created.ID, created.Email); err != nil {
+		fmt.Println(err)
+	}
+	return created, nil
+}
@@ -7,20 +7,31 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/TeamA166/WonderTrip/internal/api/throttle"
 	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
 	"github.com/TeamA166/WonderTrip/internal/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
+const maxResetCodeAttempts = 5
+
 type PasswordResetHandler struct {
-	ResetRepo repository.PResetRepository
-	UserRepo  repository.UserRepository
+	ResetRepo   repository.PResetRepository
+	UserRepo    repository.UserRepository
+	SessionRepo repository.SessionRepository
+	Signer      *tokens.Signer
+	otpGuard    throttle.Guard
 }
 
-func NewPasswordResetHandler(rRepo repository.PResetRepository, uRepo repository.UserRepository) *PasswordResetHandler {
+func NewPasswordResetHandler(rRepo repository.PResetRepository, uRepo repository.UserRepository, sRepo repository.SessionRepository, aRepo repository.LoginAttemptRepository, signer *tokens.Signer) *PasswordResetHandler {
 	return &PasswordResetHandler{
-		ResetRepo: rRepo,
-		UserRepo:  uRepo,
+		ResetRepo:   rRepo,
+		UserRepo:    uRepo,
+		SessionRepo: sRepo,
+		Signer:      signer,
+		otpGuard:    throttle.NewGuard(aRepo, "reset_otp"),
 	}
 }
 
@@ -82,9 +93,20 @@ func (h *PasswordResetHandler) VerifyOTP(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	// 2. Database Checks (Same as before)
-	resetData, err := h.ResetRepo.GetByEmail(c.Context(), req.Email)
+	if wait := h.otpGuard.LockedFor(c.Context(), req.Email, c.IP()); wait > 0 {
+		return throttle.TooManyAttempts(c, wait)
+	}
+
+	// 2. Database Checks. The attempt is counted before the code is checked; a burned code
+	// answers like a missing one.
+	resetData, err := h.ResetRepo.ClaimAttempt(c.Context(), req.Email, maxResetCodeAttempts)
 	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
+		}
+		if wait := h.otpGuard.Fail(c.Context(), req.Email, c.IP()); wait > 0 {
+			return throttle.TooManyAttempts(c, wait)
+		}
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired code"})
 	}
 
@@ -93,11 +115,19 @@ func (h *PasswordResetHandler) VerifyOTP(c *fiber.Ctx) error {
 	}
 
 	if !utils.VerifyHash(resetData.CodeHash, req.Code) {
+		// The code itself is burned after a few misses, independent of the lockout below.
+		if resetData.Attempts >= maxResetCodeAttempts {
+			_ = h.ResetRepo.DeleteByEmail(c.Context(), req.Email)
+		}
+		if wait := h.otpGuard.Fail(c.Context(), req.Email, c.IP()); wait > 0 {
+			return throttle.TooManyAttempts(c, wait)
+		}
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Incorrect code"})
 	}
+	h.otpGuard.Succeed(c.Context(), req.Email)
 
 	// 3. Generate Token
-	token, err := utils.GenerateResetToken(req.Email)
+	token, err := utils.GenerateResetToken(h.Signer, req.Email)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Server error"})
 	}
@@ -135,7 +165,7 @@ func (h *PasswordResetHandler) ResetPassword(c *fiber.Ctx) error {
 	}
 
 	// 3. Validate the Token (Same logic)
-	email, err := utils.ValidateResetToken(tokenString)
+	email, err := utils.ValidateResetToken(h.Signer, tokenString)
 	if err != nil {
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized or expired token"})
 	}
@@ -150,6 +180,13 @@ func (h *PasswordResetHandler) ResetPassword(c *fiber.Ctx) error {
 	// 5. Cleanup
 	_ = h.ResetRepo.DeleteByEmail(c.Context(), email)
 
+	// 6. Whoever knew the old password must not stay logged in anywhere
+	if user, err := h.UserRepo.GetByEmail(c.Context(), email); err == nil {
+		if _, err := h.SessionRepo.RevokeOtherSessions(c.Context(), user.ID, uuid.Nil, "password_reset"); err != nil {
+			fmt.Printf("revoke sessions after reset for %s: %v\n", email, err)
+		}
+	}
+
 	// Clear the cookie since it's used
 	c.ClearCookie("reset_token")
 
@@ -0,0 +1,36 @@
+package public
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/TeamA166/WonderTrip/internal/tokens"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PhotoLinkHandler struct {
+	signer *tokens.Signer
+}
+
+func NewPhotoLinkHandler(signer *tokens.Signer) *PhotoLinkHandler {
+	return &PhotoLinkHandler{signer: signer}
+}
+
+// GET /api/v1/photos/:filename?token=
+// Serves a post photo to whoever holds a signed link for it, such as the photo_url in a
+// GeoJSON/GPX/KML export opened in a mapping tool that cannot send a bearer token.
+func (h *PhotoLinkHandler) GetPhoto(c *fiber.Ctx) error {
+	filename := c.Params("filename")
+	if filename == "" || filename != filepath.Base(filename) || strings.Contains(filename, "..") {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid filename"})
+	}
+
+	claims, err := h.signer.Parse(c.Query("token"), tokens.TypePhoto)
+	if err != nil || claims["file"] != filename {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "Invalid or expired link"})
+	}
+
+	const uploadDir = "uploads/photos"
+	return c.SendFile(uploadDir + "/" + filename)
+}
@@ -0,0 +1,98 @@
+// Package throttle locks out repeated failures of codes and passwords per account and per IP.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// A single account is locked quickly; it only ever has one legitimate owner.
+	accountLockout = core.LockoutPolicy{
+		MaxFailures: 5,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+		ResetAfter:  24 * time.Hour,
+	}
+	// An IP may be shared (NAT, campus Wi-Fi), so it gets more room before locking.
+	ipLockout = core.LockoutPolicy{
+		MaxFailures: 20,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+		ResetAfter:  24 * time.Hour,
+	}
+)
+
+// Guard tracks failed attempts per account and per IP for one endpoint.
+type Guard struct {
+	repo  repository.LoginAttemptRepository
+	scope string
+}
+
+// NewGuard keys its counters by scope, so each guarded endpoint locks out on its own.
+func NewGuard(repo repository.LoginAttemptRepository, scope string) Guard {
+	return Guard{repo: repo, scope: scope}
+}
+
+// An account is an email address or a user ID, depending on what the endpoint knows.
+func (g Guard) accountKey(account string) string { return g.scope + ":account:" + account }
+func (g Guard) ipKey(ip string) string           { return g.scope + ":ip:" + ip }
+
+// LockedFor returns how long the caller still has to wait, or 0 if the attempt may proceed.
+// Storage errors fail open so a database hiccup never locks everyone out.
+func (g Guard) LockedFor(ctx context.Context, account, ip string) time.Duration {
+	until, err := g.repo.GetLockedUntil(ctx, g.accountKey(account), g.ipKey(ip))
+	if err != nil {
+		fmt.Printf("check lockout: %v\n", err)
+		return 0
+	}
+	if until.IsZero() {
+		return 0
+	}
+	return time.Until(until)
+}
+
+// Fail records a failed attempt and returns the wait if it triggered a lockout.
+func (g Guard) Fail(ctx context.Context, account, ip string) time.Duration {
+	var wait time.Duration
+	for key, policy := range map[string]core.LockoutPolicy{g.accountKey(account): accountLockout, g.ipKey(ip): ipLockout} {
+		until, err := g.repo.RecordFailure(ctx, key, policy)
+		if err != nil {
+			fmt.Printf("record failed attempt: %v\n", err)
+			continue
+		}
+		if d := time.Until(until); !until.IsZero() && d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// Succeed clears the account counter. The IP counter is left alone so one valid
+// account cannot be used to wash out failures against others.
+func (g Guard) Succeed(ctx context.Context, account string) {
+	if err := g.repo.Reset(ctx, g.accountKey(account)); err != nil {
+		fmt.Printf("reset attempts: %v\n", err)
+	}
+}
+
+// TooManyAttempts answers a locked out caller with 429 and a Retry-After header.
+func TooManyAttempts(c *fiber.Ctx, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       "Too many failed attempts. Please try again later.",
+		"retry_after": seconds,
+	})
+}
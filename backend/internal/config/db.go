@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -15,17 +17,67 @@ type Config struct {
 		DBName   string `mapstructure:"dbname"`
 		SSLMode  string `mapstructure:"sslmode"`
 	} `mapstructure:"database"`
-	Auth struct {
-		JWTSecret           string `mapstructure:"jwt_secret"`
-		AccessTokenMinutes  int    `mapstructure:"access_token_minutes"`
-		PasswordHashingCost int    `mapstructure:"password_hashing_cost"`
-	} `mapstructure:"auth"`
-	Password struct {
-		JWTSecret string `mapstructure:"temp_jwt_secret"`
-	}
+	Auth AuthConfig `mapstructure:"auth"`
 	Mail struct {
 		AppKey string `mapstructure:"app_key"`
 	}
+	Account struct {
+		DeletionGraceDays int `mapstructure:"deletion_grace_days"`
+	} `mapstructure:"account"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Realtime  RealtimeConfig  `mapstructure:"realtime"`
+	OIDC      struct {
+		Providers map[string]OIDCProvider `mapstructure:"providers"`
+	} `mapstructure:"oidc"`
+}
+
+// OIDCProvider configures one "Sign in with ..." provider under oidc.providers.<name>.
+// RedirectURL may be left empty; it then defaults to this server's callback route.
+type OIDCProvider struct {
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+type AuthConfig struct {
+	JWTSecret            string       `mapstructure:"jwt_secret"`
+	SigningKeys          []SigningKey `mapstructure:"signing_keys"`
+	AccessTokenMinutes   int          `mapstructure:"access_token_minutes"`
+	RefreshTokenDays     int          `mapstructure:"refresh_token_days"`
+	PasswordHashingCost  int          `mapstructure:"password_hashing_cost"`
+	RequireVerifiedEmail bool         `mapstructure:"require_verified_email"`
+}
+
+// SigningKey is one entry of auth.signing_keys. Algorithm is RS256, EdDSA or HS256;
+// asymmetric keys come from a PEM file or inline PEM, HS256 keys from Secret.
+type SigningKey struct {
+	ID             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Status         string `mapstructure:"status"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PrivateKey     string `mapstructure:"private_key"`
+	Secret         string `mapstructure:"secret"`
+}
+
+type RateLimitConfig struct {
+	Enabled  bool                       `mapstructure:"enabled"`
+	Backend  string                     `mapstructure:"backend"`
+	Policies map[string]RateLimitPolicy `mapstructure:"policies"`
+}
+
+// RateLimitPolicy allows Requests per Per on average, with bursts of up to Burst requests.
+type RateLimitPolicy struct {
+	Requests int           `mapstructure:"requests"`
+	Per      time.Duration `mapstructure:"per"`
+	Burst    int           `mapstructure:"burst"`
+}
+
+// RealtimeConfig picks how live events reach clients. "memory" only reaches clients of the
+// same instance; "postgres" uses LISTEN/NOTIFY so several instances can run side by side.
+type RealtimeConfig struct {
+	Backend string `mapstructure:"backend"`
 }
 
 func LoadConfig() (config Config, err error) {
@@ -35,6 +87,11 @@ func LoadConfig() (config Config, err error) {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("account.deletion_grace_days", 30)
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.backend", "memory")
+	viper.SetDefault("realtime.backend", "memory")
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
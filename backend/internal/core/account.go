@@ -0,0 +1,54 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeleteAccountRequest confirms a deletion with the password, or with an emailed code
+// for accounts that sign in through a login provider.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type AccountDeletionCode struct {
+	UserID    uuid.UUID `db:"user_id"`
+	CodeHash  string    `db:"code_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// Export rows are plain snapshots of what the user owns or did; they are written to the ZIP as JSON.
+
+type ExportProfile struct {
+	ID                   uuid.UUID  `json:"id" db:"id"`
+	Email                string     `json:"email" db:"email"`
+	Name                 string     `json:"name" db:"name"`
+	Surname              string     `json:"surname" db:"surname"`
+	Role                 string     `json:"role" db:"role"`
+	Bio                  string     `json:"bio" db:"bio"`
+	HomeCity             string     `json:"home_city" db:"home_city"`
+	Website              string     `json:"website" db:"website"`
+	Gender               string     `json:"gender" db:"gender"`
+	ProfilePath          string     `json:"-" db:"profile_path"`
+	EmailVerifiedAt      *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	DeletionScheduledFor *time.Time `json:"deletion_scheduled_for,omitempty" db:"deletion_scheduled_for"`
+	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type ExportComment struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	PostID    uuid.UUID `json:"post_id" db:"post_id"`
+	PostTitle string    `json:"post_title" db:"post_title"`
+	Content   string    `json:"content" db:"content"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ExportReaction is used for both likes and favorites.
+type ExportReaction struct {
+	PostID    uuid.UUID `json:"post_id" db:"post_id"`
+	PostTitle string    `json:"post_title" db:"post_title"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -6,12 +6,56 @@ import (
 	"github.com/google/uuid"
 )
 
+// MaxCommentDepth is the deepest a reply may be nested; top-level comments have depth 0.
+const MaxCommentDepth = 2
+
+// Orderings for top-level comments. Replies are always oldest first.
+const (
+	CommentSortOldest = "oldest"
+	CommentSortNewest = "newest"
+	CommentSortTop    = "top"
+)
+
+func IsValidCommentSort(sort string) bool {
+	switch sort {
+	case CommentSortOldest, CommentSortNewest, CommentSortTop:
+		return true
+	}
+	return false
+}
+
+// Comment is returned as a tombstone once deleted: Deleted is true and the content
+// and author details are blanked, but the ID stays so replies keep their parent.
 type Comment struct {
-	ID            uuid.UUID `json:"id"`
-	PostID        uuid.UUID `json:"post_id"`
-	UserID        uuid.UUID `json:"user_id"`
-	Content       string    `json:"content"`
-	CreatedAt     time.Time `json:"created_at"`
-	UserName      string    `json:"user_name"`
-	UserPhotoPath string    `json:"user_photo_path"`
+	ID            uuid.UUID    `json:"id" db:"id"`
+	PostID        uuid.UUID    `json:"post_id" db:"post_id"`
+	ParentID      *uuid.UUID   `json:"parent_id" db:"parent_id"`
+	Depth         int          `json:"depth" db:"depth"`
+	UserID        uuid.UUID    `json:"user_id" db:"user_id"`
+	Content       string       `json:"content" db:"content"`
+	Entities      TextEntities `json:"entities" db:"entities"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	EditedAt      *time.Time   `json:"edited_at" db:"edited_at"`
+	Deleted       bool         `json:"deleted" db:"deleted"`
+	ReplyCount    int          `json:"reply_count" db:"reply_count"`
+	LikeCount     int          `json:"like_count" db:"like_count"`
+	IsLiked       bool         `json:"is_liked" db:"is_liked"`
+	UserName      string       `json:"user_name" db:"user_name"`
+	UserPhotoPath string       `json:"user_photo_path" db:"user_photo_path"`
+}
+
+type CreateCommentRequest struct {
+	Content  string     `json:"content"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content"`
+}
+
+type CommentPage struct {
+	Comments []Comment `json:"comments"`
+	Page     int       `json:"page"`
+	Limit    int       `json:"limit"`
+	Total    int       `json:"total"`
 }
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailChange struct {
+	UserID          uuid.UUID  `db:"user_id"`
+	OldEmail        string     `db:"old_email"`
+	NewEmail        string     `db:"new_email"`
+	CodeHash        string     `db:"code_hash"`
+	ExpiresAt       time.Time  `db:"expires_at"`
+	ConfirmedAt     *time.Time `db:"confirmed_at"`
+	RevertExpiresAt *time.Time `db:"revert_expires_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+}
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email"`
+	Password string `json:"password"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code"`
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailVerification struct {
+	UserID    uuid.UUID `db:"user_id"`
+	CodeHash  string    `db:"code_hash"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type VerifyEmailRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
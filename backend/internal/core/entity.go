@@ -0,0 +1,74 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EntityMention = "mention"
+	EntityHashtag = "hashtag"
+)
+
+// TextEntity marks an @mention or #hashtag inside a description or comment.
+// Start and End are UTF-16 code unit offsets (End exclusive), which is what
+// JavaScript, Swift and Kotlin strings index by. Value is the normalized
+// username or tag without its prefix.
+type TextEntity struct {
+	Type   string     `json:"type"`
+	Value  string     `json:"value"`
+	Start  int        `json:"start"`
+	End    int        `json:"end"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+// TextEntities is stored as a JSONB column next to the text it describes.
+type TextEntities []TextEntity
+
+func (e TextEntities) Value() (driver.Value, error) {
+	if e == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(e)
+}
+
+func (e *TextEntities) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*e = TextEntities{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("core: cannot scan %T into TextEntities", src)
+	}
+	return json.Unmarshal(data, e)
+}
+
+// Tags returns the distinct hashtag values in order of first appearance.
+func (e TextEntities) Tags() []string {
+	return e.distinct(EntityHashtag)
+}
+
+// Usernames returns the distinct mentioned usernames in order of first appearance.
+func (e TextEntities) Usernames() []string {
+	return e.distinct(EntityMention)
+}
+
+func (e TextEntities) distinct(entityType string) []string {
+	seen := map[string]bool{}
+	var values []string
+	for _, entity := range e {
+		if entity.Type == entityType && !seen[entity.Value] {
+			seen[entity.Value] = true
+			values = append(values, entity.Value)
+		}
+	}
+	return values
+}
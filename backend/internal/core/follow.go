@@ -0,0 +1,30 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FollowUser is one entry of a follower or following list.
+// IsFollowing tells whether the viewer follows this user.
+type FollowUser struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Surname     string    `json:"surname" db:"surname"`
+	ProfilePath string    `json:"profile_path" db:"profile_path"`
+	FollowedAt  time.Time `json:"followed_at" db:"followed_at"`
+	IsFollowing bool      `json:"is_following" db:"is_following"`
+}
+
+type FollowListResponse struct {
+	Users []FollowUser `json:"users"`
+	Page  int          `json:"page"`
+	Limit int          `json:"limit"`
+	Total int          `json:"total"`
+}
+
+type FollowCounts struct {
+	Followers int `json:"followers" db:"followers"`
+	Following int `json:"following" db:"following"`
+}
@@ -0,0 +1,27 @@
+package core
+
+import "github.com/google/uuid"
+
+// ImportedPlace is one waypoint/feature read from an uploaded file.
+// Problem is set when the item could not be read at all (e.g. missing geometry).
+type ImportedPlace struct {
+	Title       string
+	Description string
+	Rating      *int
+	Latitude    float64
+	Longitude   float64
+	Problem     string
+}
+
+type ImportItemResult struct {
+	Index  int        `json:"index"`
+	Title  string     `json:"title"`
+	PostID *uuid.UUID `json:"post_id,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+type ImportResponse struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Results []ImportItemResult `json:"results"`
+}
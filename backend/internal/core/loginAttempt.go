@@ -0,0 +1,24 @@
+package core
+
+import "time"
+
+// LockoutPolicy locks a key after MaxFailures consecutive failures.
+// Each further lockout doubles the previous one, up to MaxLockout.
+type LockoutPolicy struct {
+	MaxFailures int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	// Failures older than ResetAfter are forgotten, including the lockout history.
+	ResetAfter time.Duration
+}
+
+func (p LockoutPolicy) LockoutFor(previousLockouts int) time.Duration {
+	d := p.BaseLockout
+	for i := 0; i < previousLockouts && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	if d > p.MaxLockout {
+		d = p.MaxLockout
+	}
+	return d
+}
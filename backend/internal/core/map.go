@@ -0,0 +1,27 @@
+package core
+
+import "github.com/google/uuid"
+
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// PostCluster is a grid cell of the map viewport collapsed into one marker.
+// PostID is only set when the cell holds a single post, so the app can open it directly.
+type PostCluster struct {
+	Latitude  float64    `json:"latitude"`
+	Longitude float64    `json:"longitude"`
+	Count     int        `json:"count"`
+	PostID    *uuid.UUID `json:"post_id,omitempty"`
+}
+
+type MapResponse struct {
+	Zoom      int           `json:"zoom"`
+	Clustered bool          `json:"clustered"`
+	Clusters  []PostCluster `json:"clusters"`
+	Posts     []Post        `json:"posts"`
+	Truncated bool          `json:"truncated"`
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserMFA struct {
+	UserID       uuid.UUID  `db:"user_id"`
+	Secret       string     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+func (m UserMFA) Enabled() bool {
+	return m.EnabledAt != nil
+}
+
+type RecoveryCode struct {
+	ID       uuid.UUID `db:"id"`
+	Prefix   string    `db:"code_prefix"`
+	CodeHash string    `db:"code_hash"`
+}
+
+type MFAEnrollRequest struct {
+	Password string `json:"password"`
+}
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type MFAConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+type MFADisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFALoginRequest completes a login that answered with mfa_required.
+// Either Code (from the authenticator app) or RecoveryCode must be set.
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+type RejectPostRequest struct {
+	Reason string `json:"reason"`
+}
+
+type BulkModerationRequest struct {
+	PostIDs []uuid.UUID `json:"post_ids"`
+	Action  string      `json:"action"`
+	Reason  string      `json:"reason"`
+}
+
+const (
+	EventCreated  = "created"
+	EventEdited   = "edited"
+	EventApproved = "approved"
+	EventRejected = "rejected"
+	EventDeleted  = "deleted"
+)
+
+type ModerationEvent struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	PostID      uuid.UUID  `json:"post_id" db:"post_id"`
+	PostOwnerID *uuid.UUID `json:"post_owner_id" db:"post_owner_id"`
+	ActorID     *uuid.UUID `json:"actor_id" db:"actor_id"`
+	ActorName   string     `json:"actor_name" db:"actor_name"`
+	EventType   string     `json:"event_type" db:"event_type"`
+	FromStatus  string     `json:"from_status,omitempty" db:"from_status"`
+	ToStatus    string     `json:"to_status,omitempty" db:"to_status"`
+	Reason      string     `json:"reason,omitempty" db:"reason"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationLike         = "like"
+	NotificationFavorite     = "favorite"
+	NotificationComment      = "comment"
+	NotificationReply        = "reply"
+	NotificationMention      = "mention"
+	NotificationFollow       = "follow"
+	NotificationPostApproved = "post_approved"
+	NotificationPostRejected = "post_rejected"
+)
+
+// NotificationTypes lists every type a user can mute.
+var NotificationTypes = []string{
+	NotificationLike,
+	NotificationFavorite,
+	NotificationComment,
+	NotificationReply,
+	NotificationMention,
+	NotificationFollow,
+	NotificationPostApproved,
+	NotificationPostRejected,
+}
+
+func IsValidNotificationType(t string) bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+type Notification struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	RecipientID    uuid.UUID  `json:"-" db:"recipient_id"`
+	Type           string     `json:"type" db:"type"`
+	ActorID        *uuid.UUID `json:"actor_id" db:"actor_id"`
+	ActorName      string     `json:"actor_name" db:"actor_name"`
+	ActorPhotoPath string     `json:"actor_photo_path" db:"actor_photo_path"`
+	PostID         *uuid.UUID `json:"post_id" db:"post_id"`
+	PostTitle      string     `json:"post_title" db:"post_title"`
+	CommentID      *uuid.UUID `json:"comment_id" db:"comment_id"`
+	Message        string     `json:"message,omitempty" db:"message"`
+	ReadAt         *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NotificationCursor points at the last notification of a page; the next page starts after it.
+type NotificationCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+	UnreadCount   int            `json:"unread_count"`
+}
+
+type NotificationPreferences struct {
+	Muted []string `json:"muted"`
+}
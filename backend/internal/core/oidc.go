@@ -0,0 +1,26 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OIDCLoginState struct {
+	StateHash    string    `db:"state_hash"`
+	Provider     string    `db:"provider"`
+	CodeVerifier string    `db:"code_verifier"`
+	Nonce        string    `db:"nonce"`
+	RedirectURL  string    `db:"redirect_url"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+type UserIdentity struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Provider    string    `json:"provider" db:"provider"`
+	Subject     string    `json:"-" db:"subject"`
+	Email       string    `json:"email" db:"email"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at" db:"last_login_at"`
+}
@@ -6,6 +6,7 @@ type PasswordReset struct {
 	Email     string    `db:"email"`
 	CodeHash  string    `db:"code_hash"`
 	ExpiresAt time.Time `db:"expires_at"`
+	Attempts  int       `db:"attempts"`
 }
 
 type ForgotPasswordRequest struct {
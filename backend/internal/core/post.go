@@ -7,21 +7,35 @@ import (
 )
 
 type Post struct {
-	ID            uuid.UUID `json:"id" db:"id"`
-	UserID        uuid.UUID `json:"user_id" db:"user_id"`
-	Title         string    `json:"title" db:"title"`
-	Description   string    `json:"description" db:"description"`
-	Rating        int       `json:"rating" db:"rating"`
-	Coordinates   string    `json:"coordinates" db:"coordinates"`
-	PhotoPath     string    `json:"photo_path" db:"photo_path"`
-	Verified      bool      `json:"verified" db:"verified"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
-	UserName      string    `json:"user_name"`
-	UserPhotoPath string    `json:"user_photo_path"`
-	LikeCount     int       `json:"like_count"`
-	IsLiked       bool      `json:"is_liked"`
-	IsFavorited   bool      `json:"is_favorited"`
+	ID               uuid.UUID    `json:"id" db:"id"`
+	UserID           uuid.UUID    `json:"user_id" db:"user_id"`
+	Title            string       `json:"title" db:"title"`
+	Description      string       `json:"description" db:"description"`
+	Rating           int          `json:"rating" db:"rating"`
+	Coordinates      string       `json:"coordinates" db:"coordinates"`
+	Latitude         *float64     `json:"latitude" db:"latitude"`
+	Longitude        *float64     `json:"longitude" db:"longitude"`
+	CountryCode      *string      `json:"country_code,omitempty" db:"country_code"`
+	Entities         TextEntities `json:"entities" db:"entities"`
+	PhotoPath        string       `json:"photo_path" db:"photo_path"`
+	Verified         bool         `json:"verified" db:"verified"`
+	Draft            bool         `json:"draft" db:"draft"`
+	ModerationStatus string       `json:"moderation_status" db:"moderation_status"`
+	RejectionReason  string       `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at" db:"updated_at"`
+	UserName         string       `json:"user_name"`
+	UserPhotoPath    string       `json:"user_photo_path"`
+	LikeCount        int          `json:"like_count"`
+	IsLiked          bool         `json:"is_liked"`
+	IsFavorited      bool         `json:"is_favorited"`
+	DistanceKm       *float64     `json:"distance_km,omitempty"`
+}
+
+// IsListed reports whether the post shows up for everyone in feeds, tags, profiles and live
+// streams. Drafts and rejected posts are only shown to their author and to moderators.
+func (p Post) IsListed() bool {
+	return !p.Draft && p.ModerationStatus != ModerationRejected
 }
 
 type PostPublishReq struct {
@@ -29,5 +43,7 @@ type PostPublishReq struct {
 	Description string `json:"description"`
 	Rating      *int   `json:"rating,omitempty"`
 	Coordinates string `json:"coordinates"`
+	CountryCode string `json:"country_code,omitempty"`
 	PhotoPath   string `json:"photo_path"`
+	Draft       bool   `json:"draft"`
 }
@@ -1,15 +1,63 @@
 package core
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type ProfileResponse struct {
-	ID      string `json:"id"`
-	Email   string `json:"email"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Surname   string `json:"surname"`
+	Username  string `json:"username"`
+	Bio       string `json:"bio"`
+	HomeCity  string `json:"home_city"`
+	Website   string `json:"website"`
+	Gender    string `json:"gender"`
+	Followers int    `json:"followers"`
+	Following int    `json:"following"`
+}
+
+// PublicProfile is what other users may see; it deliberately has no email.
+type PublicProfile struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Surname     string    `json:"surname"`
+	Username    string    `json:"username"`
+	ProfilePath string    `json:"profile_path"`
+	Bio         string    `json:"bio"`
+	HomeCity    string    `json:"home_city"`
+	Website     string    `json:"website"`
+	JoinedAt    time.Time `json:"joined_at"`
+	Followers   int       `json:"followers"`
+	Following   int       `json:"following"`
+	IsFollowing bool      `json:"is_following"`
+	Stats       UserStats `json:"stats"`
 }
+
+// UserStats only counts published posts that were not rejected.
+type UserStats struct {
+	Posts            int      `json:"posts"`
+	VerifiedPosts    int      `json:"verified_posts"`
+	LikesReceived    int      `json:"likes_received"`
+	AverageRating    *float64 `json:"average_rating"`
+	CountriesVisited int      `json:"countries_visited"`
+	Countries        []string `json:"countries"`
+}
+
+// UpdateProfileRequest is a partial update: omitted (or null) fields are left alone.
+// Bio, HomeCity and Website are cleared by sending "", Gender by sending "not_set".
 type UpdateProfileRequest struct {
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
-	Email   string `json:"email"`
+	Name     *string `json:"name"`
+	Surname  *string `json:"surname"`
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+	Bio      *string `json:"bio"`
+	HomeCity *string `json:"home_city"`
+	Website  *string `json:"website"`
+	Gender   *string `json:"gender"`
 }
 type ChangePasswordReq struct {
 	OldPassword string `json:"old_password"`
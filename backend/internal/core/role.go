@@ -0,0 +1,44 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+type RoleChange struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	OldRole   string     `json:"old_role" db:"old_role"`
+	NewRole   string     `json:"new_role" db:"new_role"`
+	ChangedBy *uuid.UUID `json:"changed_by" db:"changed_by"`
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+type UpdateRoleRequest struct {
+	Role   string `json:"role"`
+	Reason string `json:"reason"`
+}
+
+type StaffMember struct {
+	ID      uuid.UUID `json:"id" db:"id"`
+	Name    string    `json:"name" db:"name"`
+	Surname string    `json:"surname" db:"surname"`
+	Email   string    `json:"email" db:"email"`
+	Role    string    `json:"role" db:"role"`
+}
@@ -7,15 +7,37 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	Name         string    `json:"name" db:"name"`
-	Role         string    `json:"role" db:"role"`
-	Surname      string    `json:"surname" db:"surname"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	ProfilePath  string    `json:"profile_path" db:"profile_path"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Email           string     `json:"email" db:"email"`
+	Name            string     `json:"name" db:"name"`
+	Role            string     `json:"role" db:"role"`
+	Surname         string     `json:"surname" db:"surname"`
+	Username        string     `json:"username" db:"username"`
+	PasswordHash    string     `json:"-" db:"password_hash"`
+	ProfilePath     string     `json:"profile_path" db:"profile_path"`
+	Bio             string     `json:"bio" db:"bio"`
+	HomeCity        string     `json:"home_city" db:"home_city"`
+	Website         string     `json:"website" db:"website"`
+	Gender          string     `json:"gender" db:"gender"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Gender values mirror the CHECK constraint on users.gender.
+const (
+	GenderMale           = "male"
+	GenderFemale         = "female"
+	GenderPreferNotToSay = "prefer_not_to_say"
+	GenderNotSet         = "not_set"
+)
+
+func IsValidGender(gender string) bool {
+	switch gender {
+	case GenderMale, GenderFemale, GenderPreferNotToSay, GenderNotSet:
+		return true
+	}
+	return false
 }
 
 type RegisterRequest struct {
@@ -31,6 +53,8 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int64  `json:"expires_in"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in,omitempty"`
 }
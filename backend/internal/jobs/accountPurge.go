@@ -0,0 +1,84 @@
+// Package jobs holds background work that runs inside the API process.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/repository"
+	"github.com/TeamA166/WonderTrip/internal/utils"
+)
+
+const purgeBatchSize = 50
+
+// AccountPurger hard-deletes accounts whose deletion grace period has ended,
+// together with the photos they uploaded.
+type AccountPurger struct {
+	repo     repository.AccountRepository
+	interval time.Duration
+}
+
+func NewAccountPurger(repo repository.AccountRepository, interval time.Duration) *AccountPurger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &AccountPurger{repo: repo, interval: interval}
+}
+
+// Run purges once immediately and then on every tick until ctx is cancelled.
+func (p *AccountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *AccountPurger) purge(ctx context.Context) {
+	for {
+		ids, err := p.repo.GetDueDeletions(ctx, purgeBatchSize)
+		if err != nil {
+			fmt.Printf("account purge: %v\n", err)
+			return
+		}
+
+		deleted := 0
+		for _, id := range ids {
+			paths, err := p.repo.DeleteAccount(ctx, id)
+			if err != nil {
+				// Cancelled by a login between listing and deleting.
+				if !errors.Is(err, sql.ErrNoRows) {
+					fmt.Printf("account purge %s: %v\n", id, err)
+				}
+				continue
+			}
+
+			for _, path := range paths {
+				if !utils.IsUserUpload(path) {
+					continue
+				}
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					fmt.Printf("account purge %s: remove %s: %v\n", id, path, err)
+				}
+			}
+			deleted++
+			fmt.Printf("account purge: deleted user %s\n", id)
+		}
+
+		// A full batch that made no progress would only fail the same way again.
+		if len(ids) < purgeBatchSize || deleted == 0 || ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "wondertrip"
+	testClientSecret = "secret"
+	testRedirectURL  = "https://app.example/callback"
+	testCode         = "auth-code"
+	testVerifier     = "verifier-0123456789-0123456789-0123456789-0123"
+	testNonce        = "nonce-123"
+)
+
+// mockProvider is a local OpenID provider serving discovery, a token endpoint and a JWKS.
+// The token endpoint only issues an ID token for the expected code, verifier and client.
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	issuer string
+	// key is published in the JWKS under kid; signingKey signs the ID tokens.
+	key        *rsa.PrivateKey
+	signingKey *rsa.PrivateKey
+	kid        string
+
+	// claims are signed into the ID token returned by the token endpoint.
+	claims     jwt.MapClaims
+	jwksCalls  atomic.Int32
+	tokenCalls atomic.Int32
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	m := &mockProvider{t: t, key: key, signingKey: key, kid: "key-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/jwks", m.jwks)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	m.issuer = m.server.URL
+	m.claims = jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            testClientID,
+		"sub":            "user-42",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"given_name":     "Ada",
+		"family_name":    "Lovelace",
+		"nonce":          testNonce,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+	}
+	return m
+}
+
+func (m *mockProvider) provider() *Provider {
+	return NewProvider("mock", Config{
+		Issuer:       m.server.URL + "/",
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+	})
+}
+
+func (m *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 m.issuer,
+		"authorization_endpoint": m.server.URL + "/authorize",
+		"token_endpoint":         m.server.URL + "/token",
+		"jwks_uri":               m.server.URL + "/jwks",
+	})
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	m.tokenCalls.Add(1)
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	want := map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          testCode,
+		"redirect_uri":  testRedirectURL,
+		"client_id":     testClientID,
+		"client_secret": testClientSecret,
+		"code_verifier": testVerifier,
+	}
+	for field, value := range want {
+		if r.PostForm.Get(field) != value {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	writeJSON(w, map[string]string{"id_token": m.sign(m.claims), "token_type": "Bearer"})
+}
+
+func (m *mockProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	m.jwksCalls.Add(1)
+	pub := m.key.PublicKey
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockProvider) sign(claims jwt.MapClaims) string {
+	m.t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	signed, err := token.SignedString(m.signingKey)
+	if err != nil {
+		m.t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockProvider(t)
+
+	raw, err := m.provider().AuthCodeURL(context.Background(), testRedirectURL, "state-1", testNonce, testVerifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != m.server.URL+"/authorize" {
+		t.Errorf("endpoint = %q, want the discovered authorization endpoint", got)
+	}
+
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 testNonce,
+		"code_challenge":        PKCEChallenge(testVerifier),
+		"code_challenge_method": "S256",
+	}
+	for field, value := range want {
+		if got := q.Get(field); got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	m.issuer = "https://evil.example"
+
+	if _, err := m.provider().AuthCodeURL(context.Background(), testRedirectURL, "state", testNonce, testVerifier); err == nil {
+		t.Fatal("AuthCodeURL succeeded for a discovery document from another issuer")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+
+	claims, err := m.provider().Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	want := Claims{
+		Subject:       "user-42",
+		Email:         "ada@example.com",
+		EmailVerified: true,
+		GivenName:     "Ada",
+		FamilyName:    "Lovelace",
+	}
+	if claims != want {
+		t.Errorf("claims = %+v, want %+v", claims, want)
+	}
+	if n := m.tokenCalls.Load(); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+}
+
+func TestExchangeStringEmailVerified(t *testing.T) {
+	m := newMockProvider(t)
+	m.claims["email_verified"] = "true"
+
+	claims, err := m.provider().Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if !claims.EmailVerified {
+		t.Error("email_verified sent as a string was not honoured")
+	}
+}
+
+func TestExchangeRejectedGrant(t *testing.T) {
+	m := newMockProvider(t)
+
+	_, err := m.provider().Exchange(context.Background(), testCode, testRedirectURL, "another-verifier", testNonce)
+	if err == nil {
+		t.Fatal("Exchange succeeded with the wrong PKCE verifier")
+	}
+	if errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("err = %v, want a token exchange error rather than an invalid token", err)
+	}
+}
+
+func TestExchangeRejectsInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(m *mockProvider)
+	}{
+		{"nonce mismatch", func(m *mockProvider) { m.claims["nonce"] = "someone-elses" }},
+		{"missing nonce", func(m *mockProvider) { delete(m.claims, "nonce") }},
+		{"wrong audience", func(m *mockProvider) { m.claims["aud"] = "another-client" }},
+		{"wrong issuer", func(m *mockProvider) { m.claims["iss"] = "https://evil.example" }},
+		{"expired", func(m *mockProvider) { m.claims["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"missing exp", func(m *mockProvider) { delete(m.claims, "exp") }},
+		{"missing sub", func(m *mockProvider) { delete(m.claims, "sub") }},
+		{"several audiences without azp", func(m *mockProvider) { m.claims["aud"] = []string{testClientID, "another-client"} }},
+		{"signed by another key", func(m *mockProvider) {
+			forged, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				m.t.Fatalf("generate key: %v", err)
+			}
+			m.signingKey = forged
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			tt.mutate(m)
+
+			_, err := m.provider().Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce)
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestJWKSRefetchedAfterKeyRotation(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+
+	if _, err := p.Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce); err != nil {
+		t.Fatalf("first Exchange: %v", err)
+	}
+	if _, err := p.Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce); err != nil {
+		t.Fatalf("second Exchange: %v", err)
+	}
+	if n := m.jwksCalls.Load(); n != 1 {
+		t.Fatalf("jwks fetched %d times for one key, want 1", n)
+	}
+
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m.key, m.signingKey, m.kid = rotated, rotated, "key-2"
+
+	// An unknown kid within jwksMinRefresh of the last fetch is refused without asking the provider.
+	if _, err := p.Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("Exchange right after rotation: err = %v, want ErrInvalidIDToken", err)
+	}
+	if n := m.jwksCalls.Load(); n != 1 {
+		t.Fatalf("jwks fetched %d times inside the refresh window, want 1", n)
+	}
+
+	p.mu.Lock()
+	p.keysAt = time.Now().Add(-jwksMinRefresh)
+	p.mu.Unlock()
+
+	claims, err := p.Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("Exchange after rotation: %v", err)
+	}
+	if claims.Subject != "user-42" {
+		t.Errorf("subject = %q, want user-42", claims.Subject)
+	}
+	if n := m.jwksCalls.Load(); n != 2 {
+		t.Errorf("jwks fetched %d times, want 2", n)
+	}
+}
+
+func TestDiscoveryUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	p := NewProvider("mock", Config{Issuer: server.URL, ClientID: testClientID})
+	_, err := p.Exchange(context.Background(), testCode, testRedirectURL, testVerifier, testNonce)
+	if err == nil || !strings.Contains(err.Error(), "discovery") {
+		t.Fatalf("err = %v, want a discovery error", err)
+	}
+}
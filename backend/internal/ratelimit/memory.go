@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memorySweepEvery = 10 * time.Minute
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	fullTime time.Time
+}
+
+// MemoryLimiter keeps buckets in process memory. Limits are per instance.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, policy Policy) (Result, error) {
+	if policy.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	tokens, result := take(b.tokens, now.Sub(b.last), policy)
+	b.tokens, b.last = tokens, now
+	// Once the bucket would be full again it carries no state and can be dropped.
+	b.fullTime = now.Add(time.Duration((float64(policy.Burst) - tokens) / policy.Rate * float64(time.Second)))
+	return result, nil
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memorySweepEvery {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.After(b.fullTime) {
+			delete(l.buckets, key)
+		}
+	}
+}
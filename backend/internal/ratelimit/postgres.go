@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	postgresPruneEvery = 1000
+	postgresIdleTTL    = 24 * time.Hour
+)
+
+// PostgresLimiter stores buckets in rate_limit_buckets so every instance shares them.
+// Elapsed time is measured with the database clock to avoid skew between instances.
+type PostgresLimiter struct {
+	db    *sqlx.DB
+	calls atomic.Uint64
+}
+
+func NewPostgresLimiter(db *sqlx.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	if policy.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	if l.calls.Add(1)%postgresPruneEvery == 0 {
+		l.prune(ctx)
+	}
+
+	tx, err := l.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: allow: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insert = `INSERT INTO rate_limit_buckets (key, tokens) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, insert, key, policy.Burst); err != nil {
+		return Result{}, fmt.Errorf("ratelimit: allow: %w", err)
+	}
+
+	var row struct {
+		Tokens  float64 `db:"tokens"`
+		Elapsed float64 `db:"elapsed"`
+	}
+	const lookup = `
+        SELECT tokens, EXTRACT(EPOCH FROM (NOW() - updated_at))::float8 AS elapsed
+        FROM rate_limit_buckets
+        WHERE key = $1
+        FOR UPDATE`
+	if err := tx.GetContext(ctx, &row, lookup, key); err != nil {
+		return Result{}, fmt.Errorf("ratelimit: allow: %w", err)
+	}
+
+	tokens, result := take(row.Tokens, time.Duration(row.Elapsed*float64(time.Second)), policy)
+
+	const update = `UPDATE rate_limit_buckets SET tokens = $2, updated_at = NOW() WHERE key = $1`
+	if _, err := tx.ExecContext(ctx, update, key, tokens); err != nil {
+		return Result{}, fmt.Errorf("ratelimit: allow: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("ratelimit: allow: %w", err)
+	}
+	return result, nil
+}
+
+// prune drops buckets nobody has touched for a day; they would have refilled long ago.
+func (l *PostgresLimiter) prune(ctx context.Context) {
+	const query = `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1::interval`
+	if _, err := l.db.ExecContext(ctx, query, fmt.Sprintf("%d seconds", int(postgresIdleTTL.Seconds()))); err != nil {
+		fmt.Printf("ratelimit: prune buckets: %v\n", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package ratelimit implements token bucket rate limiting with pluggable storage.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/config"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// Policy is a token bucket: it holds up to Burst tokens and refills at Rate tokens per second.
+// The zero Policy never limits.
+type Policy struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+func NewPolicy(name string, requests int, per time.Duration, burst int) Policy {
+	if requests <= 0 || per <= 0 {
+		return Policy{Name: name}
+	}
+	if burst <= 0 {
+		burst = requests
+	}
+	return Policy{Name: name, Rate: float64(requests) / per.Seconds(), Burst: burst}
+}
+
+func (p Policy) Unlimited() bool {
+	return p.Rate <= 0 || p.Burst <= 0
+}
+
+// DefaultPolicies apply when the config does not override them.
+var DefaultPolicies = map[string]config.RateLimitPolicy{
+	"public":         {Requests: 60, Per: time.Minute, Burst: 30},
+	"protected":      {Requests: 300, Per: time.Minute, Burst: 100},
+	"auth":           {Requests: 10, Per: time.Minute, Burst: 10},
+	"password_reset": {Requests: 3, Per: 15 * time.Minute, Burst: 3},
+	"publish":        {Requests: 10, Per: time.Hour, Burst: 5},
+	"comment":        {Requests: 30, Per: 10 * time.Minute, Burst: 10},
+	"like":           {Requests: 120, Per: time.Minute, Burst: 30},
+	"follow":         {Requests: 60, Per: time.Minute, Burst: 20},
+}
+
+// PolicyFor resolves a named policy from the config, falling back to DefaultPolicies.
+// When rate limiting is disabled every policy is unlimited.
+func PolicyFor(cfg config.RateLimitConfig, name string) Policy {
+	if !cfg.Enabled {
+		return Policy{Name: name}
+	}
+	p, ok := cfg.Policies[name]
+	if !ok {
+		p = DefaultPolicies[name]
+	}
+	return NewPolicy(name, p.Requests, p.Per, p.Burst)
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow takes one token from the bucket identified by key.
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// New returns the limiter selected by cfg.Backend; db is only used by the postgres backend.
+func New(cfg config.RateLimitConfig, db *sqlx.DB) (Limiter, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendMemory:
+		return NewMemoryLimiter(), nil
+	case BackendPostgres:
+		return NewPostgresLimiter(db), nil
+	}
+	return nil, fmt.Errorf("ratelimit: unknown backend %q", cfg.Backend)
+}
+
+// take refills a bucket that last held tokens elapsed ago and tries to spend one token.
+// It returns the new token count alongside the result.
+func take(tokens float64, elapsed time.Duration, policy Policy) (float64, Result) {
+	burst := float64(policy.Burst)
+	if elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed.Seconds()*policy.Rate)
+	}
+
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / policy.Rate * float64(time.Second))
+		return tokens, Result{Allowed: false, RetryAfter: wait}
+	}
+
+	tokens--
+	return tokens, Result{Allowed: true, Remaining: int(tokens)}
+}
@@ -0,0 +1,106 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriptionBuffer is how far a client may fall behind before it is disconnected.
+const subscriptionBuffer = 64
+
+// Hub delivers events to subscribers in this process only.
+type Hub struct {
+	mu     sync.Mutex
+	topics map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{topics: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscription receives events on C until it is closed. C is also closed when the client
+// cannot keep up or the hub shuts down; it should then reconnect and refetch.
+type Subscription struct {
+	C <-chan Event
+
+	events chan Event
+	topics []string
+	hub    *Hub
+	closed bool
+}
+
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	events := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{C: events, events: events, topics: topics, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		sub.closed = true
+		close(events)
+		return sub
+	}
+	for _, topic := range topics {
+		subs, ok := h.topics[topic]
+		if !ok {
+			subs = make(map[*Subscription]struct{})
+			h.topics[topic] = subs
+		}
+		subs[sub] = struct{}{}
+	}
+	return sub
+}
+
+// Publish never blocks on a subscriber.
+func (h *Hub) Publish(_ context.Context, event Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.topics[event.Topic] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+	return nil
+}
+
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.topics {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+	return nil
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+
+	for _, topic := range sub.topics {
+		if subs, ok := h.topics[topic]; ok {
+			delete(subs, sub)
+			if len(subs) == 0 {
+				delete(h.topics, topic)
+			}
+		}
+	}
+}
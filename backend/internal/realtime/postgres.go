@@ -0,0 +1,97 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	postgresChannel = "wondertrip_events"
+	// NOTIFY payloads must stay below 8000 bytes. Events only carry IDs and counts,
+	// so this is a safety net rather than a limit anyone should hit.
+	postgresMaxPayload = 7900
+	postgresPingEvery  = 90 * time.Second
+)
+
+// PostgresBroker publishes through NOTIFY so every instance LISTENing on the channel
+// hands the event to its own local subscribers.
+// Events sent while an instance is reconnecting are lost to that instance's clients.
+type PostgresBroker struct {
+	db       *sqlx.DB
+	hub      *Hub
+	listener *pq.Listener
+	done     chan struct{}
+}
+
+func NewPostgresBroker(db *sqlx.DB, dsn string) (*PostgresBroker, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			fmt.Printf("realtime: listener: %v\n", err)
+		}
+	})
+	if err := listener.Listen(postgresChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("realtime: listen: %w", err)
+	}
+
+	b := &PostgresBroker{db: db, hub: NewHub(), listener: listener, done: make(chan struct{})}
+	go b.run()
+	return b, nil
+}
+
+func (b *PostgresBroker) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("realtime: publish: %w", err)
+	}
+	if len(payload) > postgresMaxPayload {
+		return fmt.Errorf("realtime: publish: %s event is %d bytes, over the NOTIFY limit", event.Type, len(payload))
+	}
+
+	if _, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, postgresChannel, string(payload)); err != nil {
+		return fmt.Errorf("realtime: publish: %w", err)
+	}
+	return nil
+}
+
+func (b *PostgresBroker) Subscribe(topics ...string) *Subscription {
+	return b.hub.Subscribe(topics...)
+}
+
+func (b *PostgresBroker) Close() error {
+	close(b.done)
+	b.hub.Close()
+	return b.listener.Close()
+}
+
+func (b *PostgresBroker) run() {
+	for {
+		select {
+		case <-b.done:
+			return
+		case n, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification means the connection was re-established.
+			if n == nil {
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				fmt.Printf("realtime: decode event: %v\n", err)
+				continue
+			}
+			b.hub.Publish(context.Background(), event)
+		case <-time.After(postgresPingEvery):
+			// Detects a dead connection that would otherwise wait forever.
+			go b.listener.Ping()
+		}
+	}
+}
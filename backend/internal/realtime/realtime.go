@@ -0,0 +1,96 @@
+// Package realtime fans live events out to connected clients with a pluggable transport.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/TeamA166/WonderTrip/internal/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+const (
+	EventCommentCreated   = "comment.created"
+	EventCommentUpdated   = "comment.updated"
+	EventCommentDeleted   = "comment.deleted"
+	EventCommentLikeCount = "comment.like_count"
+	EventPostLikeCount    = "post.like_count"
+	EventPostPublished    = "post.published"
+	EventNotification     = "notification"
+)
+
+// Event is delivered to every subscriber of Topic. Data is already encoded JSON and holds
+// IDs and counts only, never user-written text, so every event fits in a NOTIFY payload.
+type Event struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func NewEvent(topic, eventType string, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("realtime: encode %s: %w", eventType, err)
+	}
+	return Event{Topic: topic, Type: eventType, Data: encoded}, nil
+}
+
+// PostTopic carries activity on a post: comments and like counts.
+func PostTopic(postID uuid.UUID) string {
+	return "post:" + postID.String()
+}
+
+// UserTopic carries a user's public activity, such as their posts going live.
+func UserTopic(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// NotificationTopic is private to its user; only they are ever subscribed to it.
+func NotificationTopic(userID uuid.UUID) string {
+	return "notifications:" + userID.String()
+}
+
+type Broker interface {
+	// Publish delivers event to the subscribers of its topic on every instance.
+	Publish(ctx context.Context, event Event) error
+	// Subscribe starts receiving events for topics on this instance.
+	Subscribe(topics ...string) *Subscription
+	// Close ends every subscription and releases the transport.
+	Close() error
+}
+
+// New returns the broker selected by cfg.Backend. db and dsn are only used by the postgres
+// backend, which needs its own connection to LISTEN on.
+func New(cfg config.RealtimeConfig, db *sqlx.DB, dsn string) (Broker, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendMemory:
+		return NewHub(), nil
+	case BackendPostgres:
+		return NewPostgresBroker(db, dsn)
+	}
+	return nil, fmt.Errorf("realtime: unknown backend %q", cfg.Backend)
+}
+
+// Publish encodes data and publishes it, logging failures. Live events are a convenience
+// on top of the REST API, so a lost one must never fail the request that caused it.
+func Publish(ctx context.Context, broker Broker, topic, eventType string, data interface{}) {
+	if broker == nil {
+		return
+	}
+
+	event, err := NewEvent(topic, eventType, data)
+	if err == nil {
+		err = broker.Publish(ctx, event)
+	}
+	if err != nil {
+		fmt.Printf("realtime: publish %s: %v\n", eventType, err)
+	}
+}
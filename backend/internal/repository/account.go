@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountRepository interface {
+	ScheduleDeletion(ctx context.Context, userID uuid.UUID, at time.Time) error
+	CancelDeletion(ctx context.Context, userID uuid.UUID) (bool, error)
+	GetDueDeletions(ctx context.Context, limit int) ([]uuid.UUID, error)
+	DeleteAccount(ctx context.Context, userID uuid.UUID) ([]string, error)
+
+	HasLinkedIdentity(ctx context.Context, userID uuid.UUID) (bool, error)
+	SaveDeletionCode(ctx context.Context, code core.AccountDeletionCode) error
+	GetDeletionCode(ctx context.Context, userID uuid.UUID) (core.AccountDeletionCode, error)
+	DeleteDeletionCode(ctx context.Context, userID uuid.UUID) error
+
+	GetExportProfile(ctx context.Context, userID uuid.UUID) (core.ExportProfile, error)
+	GetUserComments(ctx context.Context, userID uuid.UUID) ([]core.ExportComment, error)
+	GetUserLikes(ctx context.Context, userID uuid.UUID) ([]core.ExportReaction, error)
+	GetUserFavorites(ctx context.Context, userID uuid.UUID) ([]core.ExportReaction, error)
+}
+
+type accountRepository struct {
+	db *sqlx.DB
+}
+
+func NewAccountRepository(db *sqlx.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) ScheduleDeletion(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	const query = `
+        UPDATE users SET deletion_requested_at = NOW(), deletion_scheduled_for = $2
+        WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, userID, at); err != nil {
+		return fmt.Errorf("repository: schedule account deletion: %w", err)
+	}
+	return nil
+}
+
+// CancelDeletion reports whether a pending deletion was actually cancelled.
+func (r *accountRepository) CancelDeletion(ctx context.Context, userID uuid.UUID) (bool, error) {
+	const query = `
+        UPDATE users SET deletion_requested_at = NULL, deletion_scheduled_for = NULL
+        WHERE id = $1 AND deletion_scheduled_for IS NOT NULL`
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, fmt.Errorf("repository: cancel account deletion: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *accountRepository) GetDueDeletions(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	const query = `
+        SELECT id FROM users
+        WHERE deletion_scheduled_for IS NOT NULL AND deletion_scheduled_for <= NOW()
+        ORDER BY deletion_scheduled_for
+        LIMIT $1`
+
+	ids := []uuid.UUID{}
+	if err := r.db.SelectContext(ctx, &ids, query, limit); err != nil {
+		return nil, fmt.Errorf("repository: get due deletions: %w", err)
+	}
+	return ids, nil
+}
+
+// DeleteAccount removes the user (everything else cascades; other users' replies to their
+// comments are detached rather than deleted) and returns the file paths
+// that belonged to them so the caller can remove the files once the rows are gone.
+// It does nothing and returns sql.ErrNoRows if the deletion was cancelled meanwhile.
+func (r *accountRepository) DeleteAccount(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("repository: delete account: %w", err)
+	}
+	defer tx.Rollback()
+
+	var profilePath sql.NullString
+	const lock = `
+        SELECT profile_path FROM users
+        WHERE id = $1 AND deletion_scheduled_for IS NOT NULL AND deletion_scheduled_for <= NOW()
+        FOR UPDATE`
+	if err := tx.GetContext(ctx, &profilePath, lock, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("repository: delete account: %w", err)
+	}
+
+	var paths []string
+	const photos = `SELECT photo_path FROM posts WHERE user_id = $1 AND COALESCE(photo_path, '') <> ''`
+	if err := tx.SelectContext(ctx, &paths, photos, userID); err != nil {
+		return nil, fmt.Errorf("repository: delete account: %w", err)
+	}
+	if profilePath.Valid && profilePath.String != "" {
+		paths = append(paths, profilePath.String)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("repository: delete account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repository: delete account: %w", err)
+	}
+	return paths, nil
+}
+
+// HasLinkedIdentity reports whether the user can sign in through an external login provider.
+func (r *accountRepository) HasLinkedIdentity(ctx context.Context, userID uuid.UUID) (bool, error) {
+	const query = `SELECT EXISTS(SELECT 1 FROM user_identities WHERE user_id = $1)`
+
+	var linked bool
+	if err := r.db.GetContext(ctx, &linked, query, userID); err != nil {
+		return false, fmt.Errorf("repository: check linked identity: %w", err)
+	}
+	return linked, nil
+}
+
+// SaveDeletionCode replaces any code sent earlier, so only the latest email works.
+func (r *accountRepository) SaveDeletionCode(ctx context.Context, code core.AccountDeletionCode) error {
+	const query = `
+        INSERT INTO account_deletion_codes (user_id, code_hash, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE
+        SET code_hash = EXCLUDED.code_hash, expires_at = EXCLUDED.expires_at, created_at = NOW()`
+	if _, err := r.db.ExecContext(ctx, query, code.UserID, code.CodeHash, code.ExpiresAt); err != nil {
+		return fmt.Errorf("repository: save deletion code: %w", err)
+	}
+	return nil
+}
+
+func (r *accountRepository) GetDeletionCode(ctx context.Context, userID uuid.UUID) (core.AccountDeletionCode, error) {
+	const query = `SELECT user_id, code_hash, expires_at FROM account_deletion_codes WHERE user_id = $1`
+
+	var code core.AccountDeletionCode
+	if err := r.db.GetContext(ctx, &code, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.AccountDeletionCode{}, sql.ErrNoRows
+		}
+		return core.AccountDeletionCode{}, fmt.Errorf("repository: get deletion code: %w", err)
+	}
+	return code, nil
+}
+
+func (r *accountRepository) DeleteDeletionCode(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM account_deletion_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: delete deletion code: %w", err)
+	}
+	return nil
+}
+
+func (r *accountRepository) GetExportProfile(ctx context.Context, userID uuid.UUID) (core.ExportProfile, error) {
+	const query = `
+        SELECT id, email, name, surname, COALESCE(role, 'user') AS role, COALESCE(bio, '') AS bio,
+               COALESCE(home_city, '') AS home_city, COALESCE(website, '') AS website, gender,
+               COALESCE(profile_path, '') AS profile_path, email_verified_at, deletion_scheduled_for, created_at, updated_at
+        FROM users WHERE id = $1`
+
+	var p core.ExportProfile
+	if err := r.db.GetContext(ctx, &p, query, userID); err != nil {
+		return core.ExportProfile{}, fmt.Errorf("repository: export profile: %w", err)
+	}
+	return p, nil
+}
+
+func (r *accountRepository) GetUserComments(ctx context.Context, userID uuid.UUID) ([]core.ExportComment, error) {
+	const query = `
+        SELECT c.id, c.post_id, p.title AS post_title, c.content, c.created_at
+        FROM comments c
+        JOIN posts p ON c.post_id = p.id
+        WHERE c.user_id = $1 AND c.deleted_at IS NULL
+        ORDER BY c.created_at`
+
+	comments := []core.ExportComment{}
+	if err := r.db.SelectContext(ctx, &comments, query, userID); err != nil {
+		return nil, fmt.Errorf("repository: export comments: %w", err)
+	}
+	return comments, nil
+}
+
+func (r *accountRepository) GetUserLikes(ctx context.Context, userID uuid.UUID) ([]core.ExportReaction, error) {
+	const query = `
+        SELECT l.post_id, p.title AS post_title, l.created_at
+        FROM post_likes l
+        JOIN posts p ON l.post_id = p.id
+        WHERE l.user_id = $1
+        ORDER BY l.created_at`
+
+	likes := []core.ExportReaction{}
+	if err := r.db.SelectContext(ctx, &likes, query, userID); err != nil {
+		return nil, fmt.Errorf("repository: export likes: %w", err)
+	}
+	return likes, nil
+}
+
+func (r *accountRepository) GetUserFavorites(ctx context.Context, userID uuid.UUID) ([]core.ExportReaction, error) {
+	const query = `
+        SELECT f.post_id, p.title AS post_title, f.created_at
+        FROM favorites f
+        JOIN posts p ON f.post_id = p.id
+        WHERE f.user_id = $1
+        ORDER BY f.created_at`
+
+	favorites := []core.ExportReaction{}
+	if err := r.db.SelectContext(ctx, &favorites, query, userID); err != nil {
+		return nil, fmt.Errorf("repository: export favorites: %w", err)
+	}
+	return favorites, nil
+}
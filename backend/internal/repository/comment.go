@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment core.Comment) (core.Comment, error)
+	GetByID(ctx context.Context, commentID, viewerID uuid.UUID) (core.Comment, error)
+	GetTopLevel(ctx context.Context, postID, viewerID uuid.UUID, sort string, limit, offset int) ([]core.Comment, int, error)
+	GetReplies(ctx context.Context, parentID, viewerID uuid.UUID, limit, offset int) ([]core.Comment, int, error)
+	UpdateContent(ctx context.Context, commentID, viewerID uuid.UUID, content string) (core.Comment, error)
+	SoftDelete(ctx context.Context, commentID, actorID uuid.UUID) error
+	ToggleLike(ctx context.Context, userID, commentID uuid.UUID) (bool, error)
+	IsLiked(ctx context.Context, userID, commentID uuid.UUID) (bool, error)
+	GetLikeCount(ctx context.Context, commentID uuid.UUID) (int, error)
+}
+
+type commentRepository struct {
+	db *sqlx.DB
+}
+
+func NewCommentRepository(db *sqlx.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// Tombstones keep their place in the thread but hide what was said and by whom.
+// Queries using these columns pass the viewer as $1 for is_liked.
+var commentColumns = `c.id, c.post_id, c.parent_id, c.depth, c.created_at, c.edited_at,
+               c.deleted_at IS NOT NULL AS deleted,
+               CASE WHEN c.deleted_at IS NULL THEN c.user_id ELSE '00000000-0000-0000-0000-000000000000'::uuid END AS user_id,
+               CASE WHEN c.deleted_at IS NULL THEN c.content ELSE '' END AS content,
+               CASE WHEN c.deleted_at IS NULL THEN c.entities ELSE '[]'::jsonb END AS entities,
+               CASE WHEN c.deleted_at IS NULL THEN u.name ELSE '' END AS user_name,
+               CASE WHEN c.deleted_at IS NULL THEN COALESCE(u.profile_path, '') ELSE '' END AS user_photo_path,
+               (SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id AND ` + visibleAs("r") + `) AS reply_count,
+               (SELECT COUNT(*) FROM comment_likes cl WHERE cl.comment_id = c.id) AS like_count,
+               EXISTS(SELECT 1 FROM comment_likes cl WHERE cl.comment_id = c.id AND cl.user_id = $1) AS is_liked`
+
+// visibleComment drops tombstones that no longer have any live replies to hold together.
+var visibleComment = visibleAs("c")
+
+// visibleAs matches the comment aliased as alias if it is live or has a live reply anywhere below it,
+// not just directly: a deleted reply under a deleted comment can still hold a live one.
+func visibleAs(alias string) string {
+	return `(` + alias + `.deleted_at IS NULL OR EXISTS(
+            WITH RECURSIVE descendants AS (
+                SELECT d.id, d.deleted_at FROM comments d WHERE d.parent_id = ` + alias + `.id
+                UNION ALL
+                SELECT d.id, d.deleted_at FROM comments d JOIN descendants p ON d.parent_id = p.id
+            )
+            SELECT 1 FROM descendants WHERE deleted_at IS NULL))`
+}
+
+// CreateComment derives the depth from the parent. Replies must belong to the same post,
+// the parent must still exist and be shallower than core.MaxCommentDepth, otherwise sql.ErrNoRows is returned.
+func (r *commentRepository) CreateComment(ctx context.Context, c core.Comment) (core.Comment, error) {
+	const query = `
+        INSERT INTO comments (post_id, user_id, content, parent_id, depth)
+        SELECT $1::uuid, $2::uuid, $3::text, $4::uuid, COALESCE((SELECT depth + 1 FROM comments WHERE id = $4::uuid), 0)
+        WHERE $4::uuid IS NULL
+           OR EXISTS(SELECT 1 FROM comments WHERE id = $4::uuid AND post_id = $1 AND deleted_at IS NULL AND depth < $5)
+        RETURNING id`
+
+	var id uuid.UUID
+	if err := r.db.GetContext(ctx, &id, query, c.PostID, c.UserID, c.Content, c.ParentID, core.MaxCommentDepth); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Comment{}, sql.ErrNoRows
+		}
+		return core.Comment{}, fmt.Errorf("repository: create comment: %w", err)
+	}
+	return r.GetByID(ctx, id, c.UserID)
+}
+
+func (r *commentRepository) GetByID(ctx context.Context, commentID, viewerID uuid.UUID) (core.Comment, error) {
+	query := `
+        SELECT ` + commentColumns + `
+        FROM comments c
+        JOIN users u ON c.user_id = u.id
+        WHERE c.id = $2`
+
+	var comment core.Comment
+	if err := r.db.GetContext(ctx, &comment, query, viewerID, commentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Comment{}, sql.ErrNoRows
+		}
+		return core.Comment{}, fmt.Errorf("repository: get comment: %w", err)
+	}
+	return comment, nil
+}
+
+var commentOrderBy = map[string]string{
+	core.CommentSortOldest: "c.created_at ASC, c.id",
+	core.CommentSortNewest: "c.created_at DESC, c.id",
+	// Ties go to the earlier comment, which has had longer to collect likes.
+	core.CommentSortTop: "like_count DESC, c.created_at ASC, c.id",
+}
+
+// GetTopLevel pages through a post's top-level comments in the given core.CommentSort* order
+// and also returns how many there are in total.
+func (r *commentRepository) GetTopLevel(ctx context.Context, postID, viewerID uuid.UUID, sort string, limit, offset int) ([]core.Comment, int, error) {
+	orderBy, ok := commentOrderBy[sort]
+	if !ok {
+		orderBy = commentOrderBy[core.CommentSortOldest]
+	}
+
+	query := `
+        SELECT ` + commentColumns + `
+        FROM comments c
+        JOIN users u ON c.user_id = u.id
+        WHERE c.post_id = $2 AND c.parent_id IS NULL AND ` + visibleComment + `
+        ORDER BY ` + orderBy + `
+        LIMIT $3 OFFSET $4`
+
+	countQuery := `
+        SELECT COUNT(*) FROM comments c
+        WHERE c.post_id = $1 AND c.parent_id IS NULL AND ` + visibleComment
+
+	comments := []core.Comment{}
+	if err := r.db.SelectContext(ctx, &comments, query, viewerID, postID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("repository: get comments: %w", err)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, countQuery, postID); err != nil {
+		return nil, 0, fmt.Errorf("repository: count comments: %w", err)
+	}
+	return comments, total, nil
+}
+
+// GetReplies pages through the direct replies of a comment, oldest first.
+func (r *commentRepository) GetReplies(ctx context.Context, parentID, viewerID uuid.UUID, limit, offset int) ([]core.Comment, int, error) {
+	query := `
+        SELECT ` + commentColumns + `
+        FROM comments c
+        JOIN users u ON c.user_id = u.id
+        WHERE c.parent_id = $2 AND ` + visibleComment + `
+        ORDER BY c.created_at ASC, c.id
+        LIMIT $3 OFFSET $4`
+
+	countQuery := `
+        SELECT COUNT(*) FROM comments c
+        WHERE c.parent_id = $1 AND ` + visibleComment
+
+	comments := []core.Comment{}
+	if err := r.db.SelectContext(ctx, &comments, query, viewerID, parentID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("repository: get replies: %w", err)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, countQuery, parentID); err != nil {
+		return nil, 0, fmt.Errorf("repository: count replies: %w", err)
+	}
+	return comments, total, nil
+}
+
+// UpdateContent returns sql.ErrNoRows if the comment is missing or already deleted.
+func (r *commentRepository) UpdateContent(ctx context.Context, commentID, viewerID uuid.UUID, content string) (core.Comment, error) {
+	const query = `
+        UPDATE comments SET content = $2, edited_at = NOW(), updated_at = NOW()
+        WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, commentID, content)
+	if err != nil {
+		return core.Comment{}, fmt.Errorf("repository: update comment: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return core.Comment{}, sql.ErrNoRows
+	}
+	return r.GetByID(ctx, commentID, viewerID)
+}
+
+// SoftDelete wipes the content but keeps the row as a tombstone for its replies.
+// It returns sql.ErrNoRows if the comment is missing or already deleted.
+func (r *commentRepository) SoftDelete(ctx context.Context, commentID, actorID uuid.UUID) error {
+	const query = `
+        UPDATE comments SET content = '', deleted_at = NOW(), deleted_by = $2, updated_at = NOW()
+        WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, commentID, actorID)
+	if err != nil {
+		return fmt.Errorf("repository: delete comment: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ToggleLike reports whether the comment is liked afterwards.
+// Deleted comments cannot be liked; liking one returns sql.ErrNoRows.
+func (r *commentRepository) ToggleLike(ctx context.Context, userID, commentID uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM comment_likes WHERE user_id = $1 AND comment_id = $2`, userID, commentID)
+	if err != nil {
+		return false, fmt.Errorf("repository: toggle comment like: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows > 0 {
+		return false, nil
+	}
+
+	const insert = `
+        INSERT INTO comment_likes (user_id, comment_id)
+        SELECT $1::uuid, id FROM comments WHERE id = $2 AND deleted_at IS NULL
+        ON CONFLICT (user_id, comment_id) DO NOTHING`
+
+	res, err = r.db.ExecContext(ctx, insert, userID, commentID)
+	if err != nil {
+		return false, fmt.Errorf("repository: toggle comment like: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		// Either a concurrent request liked it first or the comment is gone.
+		liked, err := r.IsLiked(ctx, userID, commentID)
+		if err != nil {
+			return false, err
+		}
+		if !liked {
+			return false, sql.ErrNoRows
+		}
+	}
+	return true, nil
+}
+
+func (r *commentRepository) IsLiked(ctx context.Context, userID, commentID uuid.UUID) (bool, error) {
+	const query = `SELECT EXISTS(SELECT 1 FROM comment_likes WHERE user_id = $1 AND comment_id = $2)`
+
+	var liked bool
+	if err := r.db.GetContext(ctx, &liked, query, userID, commentID); err != nil {
+		return false, fmt.Errorf("repository: is comment liked: %w", err)
+	}
+	return liked, nil
+}
+
+func (r *commentRepository) GetLikeCount(ctx context.Context, commentID uuid.UUID) (int, error) {
+	var count int
+	if err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM comment_likes WHERE comment_id = $1`, commentID); err != nil {
+		return 0, fmt.Errorf("repository: count comment likes: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrEmailChangeLocked is returned while the revert link of a confirmed change is still valid,
+// so a hijacked account cannot overwrite the link sent to the previous address.
+var ErrEmailChangeLocked = errors.New("a recent email change can still be reverted")
+
+type EmailChangeRepository interface {
+	SavePending(ctx context.Context, change core.EmailChange) error
+	GetPending(ctx context.Context, userID uuid.UUID) (core.EmailChange, error)
+	MarkConfirmed(ctx context.Context, userID uuid.UUID, newEmail, revertTokenHash string, revertExpiry time.Time) error
+	GetByRevertToken(ctx context.Context, revertTokenHash string) (core.EmailChange, error)
+	Revert(ctx context.Context, userID uuid.UUID, revertTokenHash string) error
+}
+
+type emailChangeRepository struct {
+	db *sqlx.DB
+}
+
+func NewEmailChangeRepository(db *sqlx.DB) EmailChangeRepository {
+	return &emailChangeRepository{db: db}
+}
+
+const emailChangeColumns = `user_id, old_email, new_email, code_hash, expires_at, confirmed_at, revert_expires_at, created_at`
+
+// SavePending replaces an earlier unconfirmed request. Confirmed changes are only replaced
+// once their revert window has closed; until then ErrEmailChangeLocked is returned.
+func (r *emailChangeRepository) SavePending(ctx context.Context, ch core.EmailChange) error {
+	const query = `
+        INSERT INTO email_changes (user_id, old_email, new_email, code_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id)
+        DO UPDATE SET
+            old_email = EXCLUDED.old_email,
+            new_email = EXCLUDED.new_email,
+            code_hash = EXCLUDED.code_hash,
+            expires_at = EXCLUDED.expires_at,
+            confirmed_at = NULL,
+            revert_token_hash = NULL,
+            revert_expires_at = NULL,
+            created_at = CURRENT_TIMESTAMP
+        WHERE email_changes.confirmed_at IS NULL OR email_changes.revert_expires_at < NOW()`
+
+	res, err := r.db.ExecContext(ctx, query, ch.UserID, ch.OldEmail, ch.NewEmail, ch.CodeHash, ch.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("repository: save email change: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrEmailChangeLocked
+	}
+	return nil
+}
+
+func (r *emailChangeRepository) GetPending(ctx context.Context, userID uuid.UUID) (core.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE user_id = $1 AND confirmed_at IS NULL`
+
+	var ch core.EmailChange
+	if err := r.db.GetContext(ctx, &ch, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.EmailChange{}, sql.ErrNoRows
+		}
+		return core.EmailChange{}, fmt.Errorf("repository: get email change: %w", err)
+	}
+	return ch, nil
+}
+
+// MarkConfirmed switches the account to newEmail, marks it verified, closes the pending request
+// and arms the revert link, all in one transaction so a changed address always has a revert link.
+// sql.ErrNoRows is returned when there is no pending request left to confirm.
+func (r *emailChangeRepository) MarkConfirmed(ctx context.Context, userID uuid.UUID, newEmail, revertTokenHash string, revertExpiry time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: confirm email change: %w", err)
+	}
+	defer tx.Rollback()
+
+	const confirm = `
+        UPDATE email_changes
+        SET confirmed_at = NOW(), revert_token_hash = $2, revert_expires_at = $3
+        WHERE user_id = $1 AND confirmed_at IS NULL`
+	res, err := tx.ExecContext(ctx, confirm, userID, revertTokenHash, revertExpiry)
+	if err != nil {
+		return fmt.Errorf("repository: confirm email change: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	const apply = `UPDATE users SET email = $2, email_verified_at = NOW(), updated_at = NOW() WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, apply, userID, newEmail); err != nil {
+		return fmt.Errorf("repository: confirm email change: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: confirm email change: %w", err)
+	}
+	return nil
+}
+
+func (r *emailChangeRepository) GetByRevertToken(ctx context.Context, revertTokenHash string) (core.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE revert_token_hash = $1 AND confirmed_at IS NOT NULL`
+
+	var ch core.EmailChange
+	if err := r.db.GetContext(ctx, &ch, query, revertTokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.EmailChange{}, sql.ErrNoRows
+		}
+		return core.EmailChange{}, fmt.Errorf("repository: get email change by revert token: %w", err)
+	}
+	return ch, nil
+}
+
+// Revert puts the previous address back and consumes the revert link in one transaction, so the
+// link works once and the account never keeps the new address without a way back.
+// sql.ErrNoRows is returned when the link was already used.
+func (r *emailChangeRepository) Revert(ctx context.Context, userID uuid.UUID, revertTokenHash string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: revert email change: %w", err)
+	}
+	defer tx.Rollback()
+
+	const consume = `
+        DELETE FROM email_changes
+        WHERE user_id = $1 AND revert_token_hash = $2 AND confirmed_at IS NOT NULL
+        RETURNING old_email`
+	var oldEmail string
+	if err := tx.GetContext(ctx, &oldEmail, consume, userID, revertTokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("repository: revert email change: %w", err)
+	}
+
+	const restore = `UPDATE users SET email = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, restore, userID, oldEmail); err != nil {
+		return fmt.Errorf("repository: revert email change: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: revert email change: %w", err)
+	}
+	return nil
+}
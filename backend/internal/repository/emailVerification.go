@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type EmailVerificationRepository interface {
+	SaveCode(ctx context.Context, userID uuid.UUID, codeHash string, expiry time.Time) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (core.EmailVerification, error)
+	MarkVerified(ctx context.Context, userID uuid.UUID) error
+	ClaimAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int) (core.EmailVerification, error)
+	DeleteCode(ctx context.Context, userID uuid.UUID) error
+}
+
+type emailVerificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewEmailVerificationRepository(db *sqlx.DB) EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+func (r *emailVerificationRepository) SaveCode(ctx context.Context, userID uuid.UUID, codeHash string, expiry time.Time) error {
+	const query = `
+        INSERT INTO email_verifications (user_id, code_hash, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id)
+        DO UPDATE SET
+            code_hash = EXCLUDED.code_hash,
+            expires_at = EXCLUDED.expires_at,
+            attempts = 0,
+            created_at = CURRENT_TIMESTAMP`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, codeHash, expiry); err != nil {
+		return fmt.Errorf("repository: save verification code: %w", err)
+	}
+	return nil
+}
+
+func (r *emailVerificationRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (core.EmailVerification, error) {
+	const query = `
+        SELECT user_id, code_hash, attempts, expires_at, created_at
+        FROM email_verifications
+        WHERE user_id = $1`
+
+	var v core.EmailVerification
+	if err := r.db.GetContext(ctx, &v, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.EmailVerification{}, sql.ErrNoRows
+		}
+		return core.EmailVerification{}, fmt.Errorf("repository: get verification code: %w", err)
+	}
+	return v, nil
+}
+
+// MarkVerified stamps the user as verified and discards the code in one transaction.
+func (r *emailVerificationRepository) MarkVerified(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: mark email verified: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: mark email verified: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_verifications WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: mark email verified: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: mark email verified: %w", err)
+	}
+	return nil
+}
+
+// ClaimAttempt counts an attempt at the code before it is checked and returns the stored code,
+// so parallel guesses cannot all slip under the limit. sql.ErrNoRows means there is no code
+// or it has used up its maxAttempts.
+func (r *emailVerificationRepository) ClaimAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int) (core.EmailVerification, error) {
+	const query = `
+        UPDATE email_verifications SET attempts = attempts + 1
+        WHERE user_id = $1 AND attempts < $2
+        RETURNING user_id, code_hash, attempts, expires_at, created_at`
+
+	var v core.EmailVerification
+	if err := r.db.GetContext(ctx, &v, query, userID, maxAttempts); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.EmailVerification{}, sql.ErrNoRows
+		}
+		return core.EmailVerification{}, fmt.Errorf("repository: claim verification attempt: %w", err)
+	}
+	return v, nil
+}
+
+func (r *emailVerificationRepository) DeleteCode(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM email_verifications WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: delete verification code: %w", err)
+	}
+	return nil
+}
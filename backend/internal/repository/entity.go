@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// EntityRepository stores the @mentions and #hashtags parsed out of posts and comments.
+type EntityRepository interface {
+	ResolveUsernames(ctx context.Context, usernames []string) (map[string]uuid.UUID, error)
+	SavePostEntities(ctx context.Context, postID uuid.UUID, entities core.TextEntities) error
+	SaveCommentEntities(ctx context.Context, commentID uuid.UUID, entities core.TextEntities) error
+}
+
+type entityRepository struct {
+	db *sqlx.DB
+}
+
+func NewEntityRepository(db *sqlx.DB) EntityRepository {
+	return &entityRepository{db: db}
+}
+
+func (r *entityRepository) ResolveUsernames(ctx context.Context, usernames []string) (map[string]uuid.UUID, error) {
+	ids := make(map[string]uuid.UUID, len(usernames))
+	if len(usernames) == 0 {
+		return ids, nil
+	}
+
+	var rows []struct {
+		ID       uuid.UUID `db:"id"`
+		Username string    `db:"username"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT id, username FROM users WHERE username = ANY($1)`, pq.Array(usernames)); err != nil {
+		return nil, fmt.Errorf("repository: resolve usernames: %w", err)
+	}
+	for _, row := range rows {
+		ids[row.Username] = row.ID
+	}
+	return ids, nil
+}
+
+// entityTables names where one kind of text stores its entities.
+type entityTables struct {
+	owner, ownerKey, hashtags, mentions string
+}
+
+var (
+	postEntityTables    = entityTables{owner: "posts", ownerKey: "post_id", hashtags: "post_hashtags", mentions: "post_mentions"}
+	commentEntityTables = entityTables{owner: "comments", ownerKey: "comment_id", hashtags: "comment_hashtags", mentions: "comment_mentions"}
+)
+
+// SavePostEntities replaces the post's stored entities and its hashtag and mention rows.
+func (r *entityRepository) SavePostEntities(ctx context.Context, postID uuid.UUID, entities core.TextEntities) error {
+	return r.save(ctx, postEntityTables, postID, entities)
+}
+
+// SaveCommentEntities replaces the comment's stored entities and its hashtag and mention rows.
+func (r *entityRepository) SaveCommentEntities(ctx context.Context, commentID uuid.UUID, entities core.TextEntities) error {
+	return r.save(ctx, commentEntityTables, commentID, entities)
+}
+
+func (r *entityRepository) save(ctx context.Context, t entityTables, ownerID uuid.UUID, entities core.TextEntities) error {
+	var mentioned []string
+	seen := map[uuid.UUID]bool{}
+	for _, e := range entities {
+		if e.Type == core.EntityMention && e.UserID != nil && !seen[*e.UserID] {
+			seen[*e.UserID] = true
+			mentioned = append(mentioned, e.UserID.String())
+		}
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: save %s entities: %w", t.owner, err)
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		arg   interface{}
+	}{
+		{`UPDATE ` + t.owner + ` SET entities = $2 WHERE id = $1`, entities},
+		{`DELETE FROM ` + t.hashtags + ` WHERE ` + t.ownerKey + ` = $1`, nil},
+		{`INSERT INTO ` + t.hashtags + ` (` + t.ownerKey + `, tag) SELECT $1::uuid, unnest($2::text[]) ON CONFLICT DO NOTHING`, pq.Array(entities.Tags())},
+		{`DELETE FROM ` + t.mentions + ` WHERE ` + t.ownerKey + ` = $1`, nil},
+		{`INSERT INTO ` + t.mentions + ` (` + t.ownerKey + `, user_id) SELECT $1::uuid, unnest($2::uuid[]) ON CONFLICT DO NOTHING`, pq.Array(mentioned)},
+	}
+	for _, st := range statements {
+		args := []interface{}{ownerID}
+		if st.arg != nil {
+			args = append(args, st.arg)
+		}
+		if _, err := tx.ExecContext(ctx, st.query, args...); err != nil {
+			return fmt.Errorf("repository: save %s entities: %w", t.owner, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: save %s entities: %w", t.owner, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type FollowRepository interface {
+	Follow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error)
+	Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error)
+	IsFollowing(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error)
+	GetFollowers(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]core.FollowUser, error)
+	GetFollowing(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]core.FollowUser, error)
+	GetCounts(ctx context.Context, userID uuid.UUID) (core.FollowCounts, error)
+}
+
+type followRepository struct {
+	db *sqlx.DB
+}
+
+func NewFollowRepository(db *sqlx.DB) FollowRepository {
+	return &followRepository{db: db}
+}
+
+// Follow reports whether a new follow was created; following twice is not an error.
+// It returns sql.ErrNoRows when the followee does not exist.
+func (r *followRepository) Follow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	const query = `
+        INSERT INTO follows (follower_id, followee_id)
+        SELECT $1::uuid, id FROM users WHERE id = $2
+        ON CONFLICT (follower_id, followee_id) DO NOTHING
+        RETURNING followee_id`
+
+	var inserted uuid.UUID
+	err := r.db.GetContext(ctx, &inserted, query, followerID, followeeID)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("repository: follow: %w", err)
+	}
+
+	// Nothing inserted: either the user is missing or the follow already existed.
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, followeeID); err != nil {
+		return false, fmt.Errorf("repository: follow: %w", err)
+	}
+	if !exists {
+		return false, sql.ErrNoRows
+	}
+	return false, nil
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("repository: unfollow: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *followRepository) IsFollowing(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	const query = `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)`
+
+	var following bool
+	if err := r.db.GetContext(ctx, &following, query, followerID, followeeID); err != nil {
+		return false, fmt.Errorf("repository: is following: %w", err)
+	}
+	return following, nil
+}
+
+const followUserColumns = `u.id, u.name, u.surname, COALESCE(u.profile_path, '') AS profile_path, f.created_at AS followed_at,
+               EXISTS(SELECT 1 FROM follows v WHERE v.follower_id = $2 AND v.followee_id = u.id) AS is_following`
+
+// GetFollowers lists the users following userID, newest first.
+func (r *followRepository) GetFollowers(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]core.FollowUser, error) {
+	const query = `
+        SELECT ` + followUserColumns + `
+        FROM follows f
+        JOIN users u ON u.id = f.follower_id
+        WHERE f.followee_id = $1
+        ORDER BY f.created_at DESC, u.id
+        LIMIT $3 OFFSET $4`
+
+	users := []core.FollowUser{}
+	if err := r.db.SelectContext(ctx, &users, query, userID, viewerID, limit, offset); err != nil {
+		return nil, fmt.Errorf("repository: get followers: %w", err)
+	}
+	return users, nil
+}
+
+// GetFollowing lists the users userID follows, newest first.
+func (r *followRepository) GetFollowing(ctx context.Context, userID, viewerID uuid.UUID, limit, offset int) ([]core.FollowUser, error) {
+	const query = `
+        SELECT ` + followUserColumns + `
+        FROM follows f
+        JOIN users u ON u.id = f.followee_id
+        WHERE f.follower_id = $1
+        ORDER BY f.created_at DESC, u.id
+        LIMIT $3 OFFSET $4`
+
+	users := []core.FollowUser{}
+	if err := r.db.SelectContext(ctx, &users, query, userID, viewerID, limit, offset); err != nil {
+		return nil, fmt.Errorf("repository: get following: %w", err)
+	}
+	return users, nil
+}
+
+func (r *followRepository) GetCounts(ctx context.Context, userID uuid.UUID) (core.FollowCounts, error) {
+	const query = `
+        SELECT (SELECT COUNT(*) FROM follows WHERE followee_id = $1) AS followers,
+               (SELECT COUNT(*) FROM follows WHERE follower_id = $1) AS following`
+
+	var counts core.FollowCounts
+	if err := r.db.GetContext(ctx, &counts, query, userID); err != nil {
+		return core.FollowCounts{}, fmt.Errorf("repository: get follow counts: %w", err)
+	}
+	return counts, nil
+}
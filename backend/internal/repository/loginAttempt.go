@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type LoginAttemptRepository interface {
+	GetLockedUntil(ctx context.Context, keys ...string) (time.Time, error)
+	RecordFailure(ctx context.Context, key string, policy core.LockoutPolicy) (time.Time, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type loginAttemptRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// GetLockedUntil returns the latest lock among keys, or the zero time if none of them is locked.
+func (r *loginAttemptRepository) GetLockedUntil(ctx context.Context, keys ...string) (time.Time, error) {
+	const query = `SELECT MAX(locked_until) FROM login_attempts WHERE key = ANY($1)`
+
+	var lockedUntil *time.Time
+	if err := r.db.GetContext(ctx, &lockedUntil, query, pq.Array(keys)); err != nil {
+		return time.Time{}, fmt.Errorf("repository: get lockout: %w", err)
+	}
+	if lockedUntil == nil || time.Now().After(*lockedUntil) {
+		return time.Time{}, nil
+	}
+	return *lockedUntil, nil
+}
+
+// RecordFailure counts a failed attempt and returns the new lock expiry when it crosses the policy threshold.
+// While the key is still locked nothing is counted and the current lock is returned, so failures that
+// raced past the lockout check cannot start a new round of attempts.
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, key string, policy core.LockoutPolicy) (time.Time, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("repository: record login failure: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO login_attempts (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, key); err != nil {
+		return time.Time{}, fmt.Errorf("repository: record login failure: %w", err)
+	}
+
+	var row struct {
+		Failures      int        `db:"failures"`
+		Lockouts      int        `db:"lockouts"`
+		LastFailureAt *time.Time `db:"last_failure_at"`
+		LockedUntil   *time.Time `db:"locked_until"`
+	}
+	const lookup = `SELECT failures, lockouts, last_failure_at, locked_until FROM login_attempts WHERE key = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &row, lookup, key); err != nil {
+		return time.Time{}, fmt.Errorf("repository: record login failure: %w", err)
+	}
+
+	now := time.Now()
+	if row.LockedUntil != nil && now.Before(*row.LockedUntil) {
+		return *row.LockedUntil, nil
+	}
+	if row.LastFailureAt != nil && now.Sub(*row.LastFailureAt) > policy.ResetAfter {
+		row.Failures, row.Lockouts = 0, 0
+	}
+
+	row.Failures++
+	var lockedUntil *time.Time
+	if row.Failures >= policy.MaxFailures {
+		until := now.Add(policy.LockoutFor(row.Lockouts))
+		lockedUntil = &until
+		row.Lockouts++
+		row.Failures = 0
+	}
+
+	const update = `
+        UPDATE login_attempts
+        SET failures = $2, lockouts = $3, locked_until = GREATEST(locked_until, $4), last_failure_at = $5
+        WHERE key = $1`
+	if _, err := tx.ExecContext(ctx, update, key, row.Failures, row.Lockouts, lockedUntil, now); err != nil {
+		return time.Time{}, fmt.Errorf("repository: record login failure: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("repository: record login failure: %w", err)
+	}
+	if lockedUntil == nil {
+		return time.Time{}, nil
+	}
+	return *lockedUntil, nil
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("repository: reset login attempts: %w", err)
+	}
+	return nil
+}
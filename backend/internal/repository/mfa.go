@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+type MFARepository interface {
+	SaveEnrollment(ctx context.Context, userID uuid.UUID, secret string) error
+	Get(ctx context.Context, userID uuid.UUID) (core.UserMFA, error)
+	Enable(ctx context.Context, userID uuid.UUID, step int64, recoveryCodes []core.RecoveryCode) error
+	Disable(ctx context.Context, userID uuid.UUID) error
+	MarkStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	GetRecoveryCodes(ctx context.Context, userID uuid.UUID, prefix string) ([]core.RecoveryCode, error)
+	UseRecoveryCode(ctx context.Context, codeID uuid.UUID) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type mfaRepository struct {
+	db *sqlx.DB
+}
+
+func NewMFARepository(db *sqlx.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+// SaveEnrollment starts (or restarts) enrollment with a new secret.
+// It refuses to touch an account that already has 2FA enabled.
+func (r *mfaRepository) SaveEnrollment(ctx context.Context, userID uuid.UUID, secret string) error {
+	const query = `
+        INSERT INTO user_mfa (user_id, secret)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id)
+        DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+        WHERE user_mfa.enabled_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("repository: save mfa enrollment: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *mfaRepository) Get(ctx context.Context, userID uuid.UUID) (core.UserMFA, error) {
+	const query = `SELECT user_id, secret, enabled_at, last_used_step, created_at FROM user_mfa WHERE user_id = $1`
+
+	var mfa core.UserMFA
+	if err := r.db.GetContext(ctx, &mfa, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.UserMFA{}, sql.ErrNoRows
+		}
+		return core.UserMFA{}, fmt.Errorf("repository: get mfa: %w", err)
+	}
+	return mfa, nil
+}
+
+// Enable finishes enrollment and replaces the recovery codes.
+func (r *mfaRepository) Enable(ctx context.Context, userID uuid.UUID, step int64, recoveryCodes []core.RecoveryCode) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: enable mfa: %w", err)
+	}
+	defer tx.Rollback()
+
+	const enable = `
+        UPDATE user_mfa SET enabled_at = NOW(), last_used_step = $2
+        WHERE user_id = $1 AND enabled_at IS NULL`
+	res, err := tx.ExecContext(ctx, enable, userID, step)
+	if err != nil {
+		return fmt.Errorf("repository: enable mfa: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: enable mfa: %w", err)
+	}
+
+	const insertCode = `INSERT INTO mfa_recovery_codes (user_id, code_prefix, code_hash) VALUES ($1, $2, $3)`
+	for _, code := range recoveryCodes {
+		if _, err := tx.ExecContext(ctx, insertCode, userID, code.Prefix, code.CodeHash); err != nil {
+			return fmt.Errorf("repository: save recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: enable mfa: %w", err)
+	}
+	return nil
+}
+
+func (r *mfaRepository) Disable(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: disable mfa: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: disable mfa: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: disable mfa: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: disable mfa: %w", err)
+	}
+	return nil
+}
+
+// MarkStepUsed records a successful TOTP step. It returns false when the step (or a later one)
+// was already used, which means the code is being replayed.
+func (r *mfaRepository) MarkStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	const query = `UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	res, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("repository: mark totp step: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows == 1, nil
+}
+
+func (r *mfaRepository) GetRecoveryCodes(ctx context.Context, userID uuid.UUID, prefix string) ([]core.RecoveryCode, error) {
+	const query = `
+        SELECT id, code_prefix, code_hash FROM mfa_recovery_codes
+        WHERE user_id = $1 AND code_prefix = $2 AND used_at IS NULL`
+
+	codes := []core.RecoveryCode{}
+	if err := r.db.SelectContext(ctx, &codes, query, userID, prefix); err != nil {
+		return nil, fmt.Errorf("repository: get recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode burns a code; false means it was used concurrently.
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, codeID uuid.UUID) (bool, error) {
+	const query = `UPDATE mfa_recovery_codes SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, codeID)
+	if err != nil {
+		return false, fmt.Errorf("repository: use recovery code: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows == 1, nil
+}
+
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	const query = `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	if err := r.db.GetContext(ctx, &count, query, userID); err != nil {
+		return 0, fmt.Errorf("repository: count recovery codes: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ModerationRepository interface {
+	SetStatus(ctx context.Context, postID, moderatorID uuid.UUID, status, reason string) error
+	BulkSetStatus(ctx context.Context, postIDs []uuid.UUID, moderatorID uuid.UUID, status, reason string) ([]uuid.UUID, error)
+	RecordEvent(ctx context.Context, event core.ModerationEvent) error
+	GetPostHistory(ctx context.Context, postID uuid.UUID) ([]core.ModerationEvent, error)
+	GetPostOwner(ctx context.Context, postID uuid.UUID) (uuid.UUID, error)
+}
+
+type moderationRepository struct {
+	db *sqlx.DB
+}
+
+func NewModerationRepository(db *sqlx.DB) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+// Drafts are never moderated; they only enter the queue once their author submits them.
+// The CTE captures the status before the update so the audit event can record the transition.
+const setModerationStatusQuery = `
+    WITH prev AS (
+        SELECT id, moderation_status FROM posts
+        WHERE draft = false AND id = ANY($4::uuid[])
+        FOR UPDATE
+    )
+    UPDATE posts p
+    SET moderation_status = $1,
+        verified = ($1 = 'approved'),
+        rejection_reason = NULLIF($2, ''),
+        moderated_by = $3,
+        moderated_at = NOW()
+    FROM prev
+    WHERE p.id = prev.id
+    RETURNING p.id, p.user_id, prev.moderation_status`
+
+const insertModerationEventQuery = `
+    INSERT INTO post_moderation_events (post_id, post_owner_id, actor_id, event_type, from_status, to_status, reason)
+    VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''))`
+
+func (r *moderationRepository) SetStatus(ctx context.Context, postID, moderatorID uuid.UUID, status, reason string) error {
+	updated, err := r.BulkSetStatus(ctx, []uuid.UUID{postID}, moderatorID, status, reason)
+	if err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// BulkSetStatus applies the same decision to every post and returns the IDs that were actually updated.
+// Each change is written to post_moderation_events in the same transaction.
+func (r *moderationRepository) BulkSetStatus(ctx context.Context, postIDs []uuid.UUID, moderatorID uuid.UUID, status, reason string) ([]uuid.UUID, error) {
+	ids := make([]string, len(postIDs))
+	for i, id := range postIDs {
+		ids[i] = id.String()
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("repository: set moderation status: %w", err)
+	}
+	defer tx.Rollback()
+
+	type change struct {
+		postID, ownerID uuid.UUID
+		fromStatus      string
+	}
+
+	rows, err := tx.QueryContext(ctx, setModerationStatusQuery, status, reason, moderatorID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository: set moderation status: %w", err)
+	}
+	var changes []change
+	for rows.Next() {
+		var ch change
+		if err := rows.Scan(&ch.postID, &ch.ownerID, &ch.fromStatus); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		changes = append(changes, ch)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	eventType := core.EventApproved
+	if status == core.ModerationRejected {
+		eventType = core.EventRejected
+	}
+
+	updated := make([]uuid.UUID, 0, len(changes))
+	for _, ch := range changes {
+		if _, err := tx.ExecContext(ctx, insertModerationEventQuery,
+			ch.postID, ch.ownerID, moderatorID, eventType, ch.fromStatus, status, reason); err != nil {
+			return nil, fmt.Errorf("repository: record moderation event: %w", err)
+		}
+		updated = append(updated, ch.postID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repository: set moderation status: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *moderationRepository) RecordEvent(ctx context.Context, e core.ModerationEvent) error {
+	_, err := r.db.ExecContext(ctx, insertModerationEventQuery,
+		e.PostID, e.PostOwnerID, e.ActorID, e.EventType, e.FromStatus, e.ToStatus, e.Reason)
+	if err != nil {
+		return fmt.Errorf("repository: record moderation event: %w", err)
+	}
+	return nil
+}
+
+func (r *moderationRepository) GetPostHistory(ctx context.Context, postID uuid.UUID) ([]core.ModerationEvent, error) {
+	const query = `
+        SELECT e.id, e.post_id, e.post_owner_id, e.actor_id,
+               COALESCE(u.name || ' ' || u.surname, '') AS actor_name,
+               e.event_type, COALESCE(e.from_status, '') AS from_status, COALESCE(e.to_status, '') AS to_status,
+               COALESCE(e.reason, '') AS reason, e.created_at
+        FROM post_moderation_events e
+        LEFT JOIN users u ON e.actor_id = u.id
+        WHERE e.post_id = $1
+        ORDER BY e.created_at ASC`
+
+	events := []core.ModerationEvent{}
+	if err := r.db.SelectContext(ctx, &events, query, postID); err != nil {
+		return nil, fmt.Errorf("repository: get moderation history: %w", err)
+	}
+	return events, nil
+}
+
+// GetPostOwner also resolves posts that have since been deleted, using their recorded history.
+func (r *moderationRepository) GetPostOwner(ctx context.Context, postID uuid.UUID) (uuid.UUID, error) {
+	const query = `
+        SELECT user_id FROM posts WHERE id = $1
+        UNION ALL
+        SELECT post_owner_id FROM post_moderation_events WHERE post_id = $1 AND post_owner_id IS NOT NULL
+        LIMIT 1`
+
+	var owner uuid.UUID
+	if err := r.db.GetContext(ctx, &owner, query, postID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.UUID{}, sql.ErrNoRows
+		}
+		return uuid.UUID{}, fmt.Errorf("repository: get post owner: %w", err)
+	}
+	return owner, nil
+}
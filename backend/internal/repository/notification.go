@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n core.Notification) (core.Notification, error)
+	Retract(ctx context.Context, n core.Notification) error
+	List(ctx context.Context, userID uuid.UUID, after *core.NotificationCursor, limit int, unreadOnly bool) ([]core.Notification, error)
+	UnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, userID, notificationID uuid.UUID) error
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetMutedTypes(ctx context.Context, userID uuid.UUID) ([]string, error)
+	SetMutedTypes(ctx context.Context, userID uuid.UUID, types []string) error
+	GetPostOwner(ctx context.Context, postID uuid.UUID) (uuid.UUID, error)
+}
+
+type notificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// dedupedNotificationTypes can be triggered over and over by toggling, so an unread
+// notification from the same actor about the same post is not repeated.
+var dedupedNotificationTypes = []string{core.NotificationLike, core.NotificationFavorite, core.NotificationFollow, core.NotificationMention}
+
+const notificationSelect = `
+        SELECT n.id, n.recipient_id, n.type, n.actor_id,
+               COALESCE(a.name || ' ' || a.surname, '') AS actor_name, COALESCE(a.profile_path, '') AS actor_photo_path,
+               n.post_id, COALESCE(p.title, '') AS post_title, n.comment_id, COALESCE(n.message, '') AS message,
+               n.read_at, n.created_at
+        FROM notifications n
+        LEFT JOIN users a ON n.actor_id = a.id
+        LEFT JOIN posts p ON n.post_id = p.id `
+
+// Create stores n unless the recipient muted its type or an identical unread one exists;
+// in that case sql.ErrNoRows is returned. The stored notification comes back with
+// actor and post details filled in.
+func (r *notificationRepository) Create(ctx context.Context, n core.Notification) (core.Notification, error) {
+	const query = `
+        INSERT INTO notifications (recipient_id, actor_id, type, post_id, comment_id, message)
+        SELECT $1::uuid, $2::uuid, $3::varchar, $4::uuid, $5::uuid, NULLIF($6::text, '')
+        WHERE NOT EXISTS (SELECT 1 FROM notification_mutes WHERE user_id = $1 AND type = $3)
+          AND NOT (
+              $3 = ANY($7::text[]) AND EXISTS (
+                  SELECT 1 FROM notifications
+                  WHERE recipient_id = $1 AND actor_id IS NOT DISTINCT FROM $2::uuid AND type = $3
+                    AND post_id IS NOT DISTINCT FROM $4::uuid AND comment_id IS NOT DISTINCT FROM $5::uuid
+                    AND read_at IS NULL))
+        RETURNING id`
+
+	var id uuid.UUID
+	err := r.db.GetContext(ctx, &id, query,
+		n.RecipientID, n.ActorID, n.Type, n.PostID, n.CommentID, n.Message, pq.Array(dedupedNotificationTypes))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Notification{}, sql.ErrNoRows
+		}
+		return core.Notification{}, fmt.Errorf("repository: create notification: %w", err)
+	}
+
+	var created core.Notification
+	if err := r.db.GetContext(ctx, &created, notificationSelect+`WHERE n.id = $1`, id); err != nil {
+		return core.Notification{}, fmt.Errorf("repository: create notification: %w", err)
+	}
+	return created, nil
+}
+
+// Retract removes a still-unread notification whose cause was undone, e.g. an unlike.
+func (r *notificationRepository) Retract(ctx context.Context, n core.Notification) error {
+	const query = `
+        DELETE FROM notifications
+        WHERE recipient_id = $1 AND actor_id IS NOT DISTINCT FROM $2::uuid AND type = $3
+          AND post_id IS NOT DISTINCT FROM $4::uuid AND read_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, n.RecipientID, n.ActorID, n.Type, n.PostID); err != nil {
+		return fmt.Errorf("repository: retract notification: %w", err)
+	}
+	return nil
+}
+
+// List returns the newest notifications first, starting after the cursor when one is given.
+func (r *notificationRepository) List(ctx context.Context, userID uuid.UUID, after *core.NotificationCursor, limit int, unreadOnly bool) ([]core.Notification, error) {
+	query := notificationSelect + `
+        WHERE n.recipient_id = $1
+          AND ($2 = false OR n.read_at IS NULL)`
+	args := []interface{}{userID, unreadOnly, limit}
+	if after != nil {
+		query += ` AND (n.created_at, n.id) < ($4::timestamptz, $5::uuid)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += `
+        ORDER BY n.created_at DESC, n.id DESC
+        LIMIT $3`
+
+	notifications := []core.Notification{}
+	if err := r.db.SelectContext(ctx, &notifications, query, args...); err != nil {
+		return nil, fmt.Errorf("repository: list notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	if err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM notifications WHERE recipient_id = $1 AND read_at IS NULL`, userID); err != nil {
+		return 0, fmt.Errorf("repository: count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead only touches the user's own notifications and returns sql.ErrNoRows otherwise.
+// Marking an already read notification again is not an error.
+func (r *notificationRepository) MarkRead(ctx context.Context, userID, notificationID uuid.UUID) error {
+	const query = `
+        UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+        WHERE id = $1 AND recipient_id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, notificationID, userID)
+	if err != nil {
+		return fmt.Errorf("repository: mark notification read: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE notifications SET read_at = NOW() WHERE recipient_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("repository: mark all notifications read: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows, nil
+}
+
+func (r *notificationRepository) GetMutedTypes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	types := []string{}
+	if err := r.db.SelectContext(ctx, &types, `SELECT type FROM notification_mutes WHERE user_id = $1 ORDER BY type`, userID); err != nil {
+		return nil, fmt.Errorf("repository: get muted notification types: %w", err)
+	}
+	return types, nil
+}
+
+// SetMutedTypes replaces the user's muted types with exactly the given ones.
+func (r *notificationRepository) SetMutedTypes(ctx context.Context, userID uuid.UUID, types []string) error {
+	if types == nil {
+		// A nil array would reach Postgres as NULL and match nothing below.
+		types = []string{}
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: set muted notification types: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notification_mutes WHERE user_id = $1 AND NOT (type = ANY($2::text[]))`, userID, pq.Array(types)); err != nil {
+		return fmt.Errorf("repository: set muted notification types: %w", err)
+	}
+	const insert = `
+        INSERT INTO notification_mutes (user_id, type)
+        SELECT $1::uuid, unnest($2::text[])
+        ON CONFLICT (user_id, type) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, insert, userID, pq.Array(types)); err != nil {
+		return fmt.Errorf("repository: set muted notification types: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: set muted notification types: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetPostOwner(ctx context.Context, postID uuid.UUID) (uuid.UUID, error) {
+	var owner uuid.UUID
+	if err := r.db.GetContext(ctx, &owner, `SELECT user_id FROM posts WHERE id = $1`, postID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.UUID{}, sql.ErrNoRows
+		}
+		return uuid.UUID{}, fmt.Errorf("repository: get post owner: %w", err)
+	}
+	return owner, nil
+}
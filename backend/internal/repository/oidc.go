@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type OIDCRepository interface {
+	SaveLoginState(ctx context.Context, state core.OIDCLoginState) error
+	ConsumeLoginState(ctx context.Context, stateHash string) (core.OIDCLoginState, error)
+	GetUserIDByIdentity(ctx context.Context, provider, subject string) (uuid.UUID, error)
+	LinkIdentity(ctx context.Context, identity core.UserIdentity) error
+	ClaimUnverifiedAccount(ctx context.Context, userID uuid.UUID, passwordHash string) error
+}
+
+type oidcRepository struct {
+	db *sqlx.DB
+}
+
+func NewOIDCRepository(db *sqlx.DB) OIDCRepository {
+	return &oidcRepository{db: db}
+}
+
+// SaveLoginState also clears expired states so abandoned logins do not pile up.
+func (r *oidcRepository) SaveLoginState(ctx context.Context, s core.OIDCLoginState) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("repository: prune oidc states: %w", err)
+	}
+
+	const query = `
+        INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, redirect_url, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := r.db.ExecContext(ctx, query, s.StateHash, s.Provider, s.CodeVerifier, s.Nonce, s.RedirectURL, s.ExpiresAt); err != nil {
+		return fmt.Errorf("repository: save oidc state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState deletes the state while reading it, so each state can complete one login only.
+func (r *oidcRepository) ConsumeLoginState(ctx context.Context, stateHash string) (core.OIDCLoginState, error) {
+	const query = `
+        DELETE FROM oidc_login_states WHERE state_hash = $1
+        RETURNING state_hash, provider, code_verifier, nonce, redirect_url, expires_at`
+
+	var s core.OIDCLoginState
+	if err := r.db.GetContext(ctx, &s, query, stateHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.OIDCLoginState{}, sql.ErrNoRows
+		}
+		return core.OIDCLoginState{}, fmt.Errorf("repository: consume oidc state: %w", err)
+	}
+	return s, nil
+}
+
+// GetUserIDByIdentity also stamps last_login_at, since it is only called while logging in.
+func (r *oidcRepository) GetUserIDByIdentity(ctx context.Context, provider, subject string) (uuid.UUID, error) {
+	const query = `
+        UPDATE user_identities SET last_login_at = NOW()
+        WHERE provider = $1 AND subject = $2
+        RETURNING user_id`
+
+	var userID uuid.UUID
+	if err := r.db.GetContext(ctx, &userID, query, provider, subject); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.UUID{}, sql.ErrNoRows
+		}
+		return uuid.UUID{}, fmt.Errorf("repository: get identity: %w", err)
+	}
+	return userID, nil
+}
+
+func (r *oidcRepository) LinkIdentity(ctx context.Context, identity core.UserIdentity) error {
+	const query = `
+        INSERT INTO user_identities (user_id, provider, subject, email)
+        VALUES ($1, $2, $3, NULLIF($4, ''))`
+	if _, err := r.db.ExecContext(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email); err != nil {
+		return fmt.Errorf("repository: link identity: %w", err)
+	}
+	return nil
+}
+
+// ClaimUnverifiedAccount hands an unverified account over to whoever proved the email through a
+// provider: it replaces the password, revokes every session and marks the email verified, all in
+// one transaction so a failure cannot leave the previous registrant half locked out.
+func (r *oidcRepository) ClaimUnverifiedAccount(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repository: claim account: %w", err)
+	}
+	defer tx.Rollback()
+
+	const claim = `
+        UPDATE users SET password_hash = $2, email_verified_at = NOW(), updated_at = NOW()
+        WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, claim, userID, passwordHash); err != nil {
+		return fmt.Errorf("repository: claim account: %w", err)
+	}
+	const revoke = `
+        UPDATE sessions SET revoked_at = NOW(), revoked_reason = 'oidc_link'
+        WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, revoke, userID); err != nil {
+		return fmt.Errorf("repository: claim account: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_verifications WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("repository: claim account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: claim account: %w", err)
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ type PResetRepository interface {
 	SaveOTP(ctx context.Context, email string, codeHash string, expiry time.Time) error
 	GetByEmail(ctx context.Context, email string) (core.PasswordReset, error)
 	DeleteByEmail(ctx context.Context, email string) error
+	ClaimAttempt(ctx context.Context, email string, maxAttempts int) (core.PasswordReset, error)
 }
 
 type pResetRepository struct {
@@ -43,7 +44,8 @@ func (r *pResetRepository) SaveOTP(ctx context.Context, email string, codeHash s
         ON CONFLICT (email) 
         DO UPDATE SET 
             code_hash = EXCLUDED.code_hash, 
-            expires_at = EXCLUDED.expires_at;`
+            expires_at = EXCLUDED.expires_at,
+            attempts = 0;`
 
 	_, err := r.db.ExecContext(ctx, query, email, codeHash, expiry)
 	if err != nil {
@@ -55,7 +57,7 @@ func (r *pResetRepository) SaveOTP(ctx context.Context, email string, codeHash s
 func (r *pResetRepository) GetByEmail(ctx context.Context, email string) (core.PasswordReset, error) {
 
 	const query = `
-        SELECT email, code_hash, expires_at 
+        SELECT email, code_hash, expires_at, attempts
         FROM password_resets 
         WHERE email = $1`
 
@@ -80,3 +82,22 @@ func (r *pResetRepository) DeleteByEmail(ctx context.Context, email string) erro
 	}
 	return nil
 }
+
+// ClaimAttempt counts an attempt at the code before it is checked and returns the stored code.
+// Claiming and counting in one statement keeps parallel guesses from all slipping under the limit.
+// sql.ErrNoRows means there is no code or it has used up its maxAttempts.
+func (r *pResetRepository) ClaimAttempt(ctx context.Context, email string, maxAttempts int) (core.PasswordReset, error) {
+	const query = `
+        UPDATE password_resets SET attempts = attempts + 1
+        WHERE email = $1 AND attempts < $2
+        RETURNING email, code_hash, expires_at, attempts`
+
+	var resetData core.PasswordReset
+	if err := r.db.GetContext(ctx, &resetData, query, email, maxAttempts); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.PasswordReset{}, sql.ErrNoRows
+		}
+		return core.PasswordReset{}, fmt.Errorf("repository: claim reset attempt: %w", err)
+	}
+	return resetData, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/utils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -17,17 +18,20 @@ type PostRepository interface {
 	DeletePost(ctx context.Context, postID uuid.UUID, userID uuid.UUID) error
 	UpdatePost(ctx context.Context, post core.Post) error
 	GetPostByID(ctx context.Context, postID uuid.UUID) (core.Post, error)
-	CreateComment(ctx context.Context, comment core.Comment) error
-	GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]core.Comment, error)
 	AddFavorite(ctx context.Context, userID, postID uuid.UUID) error
 	RemoveFavorite(ctx context.Context, userID, postID uuid.UUID) error
 	IsFavorite(ctx context.Context, userID, postID uuid.UUID) (bool, error)
 	GetFavorites(ctx context.Context, userID uuid.UUID) ([]core.Post, error)
 	GetPosts(ctx context.Context, limit int, offset int) ([]core.Post, error)
 	GetFeedPosts(ctx context.Context, limit int, offset int, excludeUserID uuid.UUID) ([]core.Post, error)
-	ToggleLike(ctx context.Context, userID, postID uuid.UUID) error
+	GetFollowingFeedPosts(ctx context.Context, limit int, offset int, userID uuid.UUID) ([]core.Post, error)
+	GetPostsByTag(ctx context.Context, tag string, limit int, offset int, viewerID uuid.UUID) ([]core.Post, error)
+	ToggleLike(ctx context.Context, userID, postID uuid.UUID) (bool, error)
 	IsLiked(ctx context.Context, userID, postID uuid.UUID) (bool, error)
 	GetLikeCount(postID uuid.UUID) (int64, error)
+	GetNearbyPosts(ctx context.Context, lat, lng, radiusKm float64, limit int) ([]core.Post, error)
+	GetPostsInBounds(ctx context.Context, box core.BoundingBox, limit int) ([]core.Post, error)
+	GetPostClusters(ctx context.Context, box core.BoundingBox, cellDegrees float64) ([]core.PostCluster, error)
 }
 
 type postRepository struct {
@@ -38,20 +42,23 @@ func NewPostRepository(db *sqlx.DB) PostRepository {
 	return &postRepository{db: db}
 }
 
+// listedPost is the SQL form of core.Post.IsListed; every feed, tag and profile listing uses it.
+const listedPost = `p.draft = false AND p.moderation_status <> 'rejected'`
+
 const postSelectQuery = `
-    SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.photo_path, p.verified, p.created_at,
+    SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.latitude, p.longitude, p.photo_path, p.verified, p.draft, p.moderation_status, COALESCE(p.rejection_reason, ''), p.country_code, p.entities, p.created_at,
            u.name, COALESCE(u.profile_path, '') 
     FROM posts p
     JOIN users u ON p.user_id = u.id `
 
 func (r *postRepository) CreatePost(ctx context.Context, post core.Post) (core.Post, error) {
 	const query = `
-		INSERT INTO posts (user_id, title, description, rating, coordinates, photo_path)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, user_id, title, description, rating, coordinates, photo_path, created_at, updated_at`
+		INSERT INTO posts (user_id, title, description, rating, coordinates, latitude, longitude, country_code, photo_path, draft)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, user_id, title, description, rating, coordinates, latitude, longitude, country_code, photo_path, draft, moderation_status, created_at, updated_at`
 
 	var created core.Post
-	if err := r.db.GetContext(ctx, &created, query, post.UserID, post.Title, post.Description, post.Rating, post.Coordinates, post.PhotoPath); err != nil {
+	if err := r.db.GetContext(ctx, &created, query, post.UserID, post.Title, post.Description, post.Rating, post.Coordinates, post.Latitude, post.Longitude, post.CountryCode, post.PhotoPath, post.Draft); err != nil {
 		return core.Post{}, fmt.Errorf("repository: create post: %w", err)
 	}
 
@@ -62,7 +69,7 @@ func (r *postRepository) GetPostsByStatus(ctx context.Context, isVerified bool,
 	// ✅ FIX: Use the shared query to get User Name & Photo
 	// We add "WHERE p.verified = $1" to filter by status
 	query := postSelectQuery + `
-        WHERE p.verified = $1 
+        WHERE p.verified = $1 AND ` + listedPost + `
         ORDER BY p.created_at DESC
         LIMIT $2 OFFSET $3`
 
@@ -81,7 +88,7 @@ func (r *postRepository) GetPostsByUserID(ctx context.Context, userID uuid.UUID)
 	// (To show if *YOU* liked these posts, we would need to pass your ID into this function too,
 	// but for now, this fixes the "0 Likes" bug).
 	const query = `
-        SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.photo_path, p.verified, p.created_at,
+        SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.latitude, p.longitude, p.photo_path, p.verified, p.draft, p.moderation_status, COALESCE(p.rejection_reason, ''), p.country_code, p.entities, p.created_at,
                u.name, COALESCE(u.profile_path, ''),
                false AS is_favorited, 
                false AS is_liked,     
@@ -103,7 +110,7 @@ func (r *postRepository) GetPostsByUserID(ctx context.Context, userID uuid.UUID)
 		// ✅ We must manually scan because we added 3 new columns (fav, liked, count)
 		// compared to the old scanner.
 		if err := rows.Scan(
-			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.PhotoPath, &p.Verified, &p.CreatedAt,
+			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.Latitude, &p.Longitude, &p.PhotoPath, &p.Verified, &p.Draft, &p.ModerationStatus, &p.RejectionReason, &p.CountryCode, &p.Entities, &p.CreatedAt,
 			&p.UserName, &p.UserPhotoPath,
 			&p.IsFavorited,
 			&p.IsLiked,
@@ -131,14 +138,16 @@ func (r *postRepository) DeletePost(ctx context.Context, postID uuid.UUID, userI
 }
 
 func (r *postRepository) UpdatePost(ctx context.Context, p core.Post) error {
-	// ✅ QUERY UPDATED: Updates photo_path, coordinates, and sets verified to FALSE
+	// ✅ QUERY UPDATED: Updates photo_path, coordinates, and sends the post back to the moderation queue
 	const query = `
         UPDATE posts 
-        SET title=$1, description=$2, rating=$3, coordinates=$4, photo_path=$5, verified=false 
-        WHERE id=$6 AND user_id=$7`
+        SET title=$1, description=$2, rating=$3, coordinates=$4, latitude=$5, longitude=$6, photo_path=$7, draft=$8,
+            verified=false, moderation_status='pending', rejection_reason=NULL, moderated_by=NULL, moderated_at=NULL,
+            country_code=$11, updated_at=NOW()
+        WHERE id=$9 AND user_id=$10`
 
 	res, err := r.db.ExecContext(ctx, query,
-		p.Title, p.Description, p.Rating, p.Coordinates, p.PhotoPath, p.ID, p.UserID,
+		p.Title, p.Description, p.Rating, p.Coordinates, p.Latitude, p.Longitude, p.PhotoPath, p.Draft, p.ID, p.UserID, p.CountryCode,
 	)
 	if err != nil {
 		return err
@@ -156,43 +165,11 @@ func (r *postRepository) GetPostByID(ctx context.Context, postID uuid.UUID) (cor
 
 	var p core.Post
 	err := r.db.QueryRowContext(ctx, query, postID).Scan(
-		&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.PhotoPath, &p.Verified, &p.CreatedAt,
+		&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.Latitude, &p.Longitude, &p.PhotoPath, &p.Verified, &p.Draft, &p.ModerationStatus, &p.RejectionReason, &p.CountryCode, &p.Entities, &p.CreatedAt,
 		&p.UserName, &p.UserPhotoPath,
 	)
 	return p, err
 }
-func (r *postRepository) CreateComment(ctx context.Context, c core.Comment) error {
-	const query = `INSERT INTO comments (post_id, user_id, content) VALUES ($1, $2, $3)`
-	_, err := r.db.ExecContext(ctx, query, c.PostID, c.UserID, c.Content)
-	return err
-}
-
-func (r *postRepository) GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]core.Comment, error) {
-	// ✅ CHANGED: Select u.photo_path
-	const query = `
-        SELECT c.id, c.post_id, c.user_id, c.content, c.created_at, u.name, u.profile_path
-        FROM comments c
-        JOIN users u ON c.user_id = u.id
-        WHERE c.post_id = $1
-        ORDER BY c.created_at ASC`
-
-	rows, err := r.db.QueryContext(ctx, query, postID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var comments []core.Comment
-	for rows.Next() {
-		var c core.Comment
-		// ✅ CHANGED: Scan the new column into UserPhotoPath
-		if err := rows.Scan(&c.ID, &c.PostID, &c.UserID, &c.Content, &c.CreatedAt, &c.UserName, &c.UserPhotoPath); err != nil {
-			return nil, err
-		}
-		comments = append(comments, c)
-	}
-	return comments, nil
-}
 func (r *postRepository) AddFavorite(ctx context.Context, userID, postID uuid.UUID) error {
 	_, err := r.db.ExecContext(ctx,
 		"INSERT INTO favorites (user_id, post_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
@@ -216,7 +193,7 @@ func (r *postRepository) IsFavorite(ctx context.Context, userID, postID uuid.UUI
 func (r *postRepository) GetFavorites(ctx context.Context, userID uuid.UUID) ([]core.Post, error) {
 	// ✅ FIX: Join with 'users' table to get Name and PhotoPath for favorites too
 	const query = `
-        SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.photo_path, p.verified, p.created_at,
+        SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.latitude, p.longitude, p.photo_path, p.verified, p.draft, p.moderation_status, COALESCE(p.rejection_reason, ''), p.country_code, p.entities, p.created_at,
                u.name, COALESCE(u.profile_path, '')
         FROM posts p
         JOIN favorites f ON p.id = f.post_id
@@ -238,7 +215,7 @@ func (r *postRepository) scanPosts(rows *sql.Rows) ([]core.Post, error) {
 	for rows.Next() {
 		var p core.Post
 		if err := rows.Scan(
-			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.PhotoPath, &p.Verified, &p.CreatedAt,
+			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.Latitude, &p.Longitude, &p.PhotoPath, &p.Verified, &p.Draft, &p.ModerationStatus, &p.RejectionReason, &p.CountryCode, &p.Entities, &p.CreatedAt,
 			&p.UserName, &p.UserPhotoPath,
 		); err != nil {
 			return nil, err
@@ -250,6 +227,7 @@ func (r *postRepository) scanPosts(rows *sql.Rows) ([]core.Post, error) {
 func (r *postRepository) GetPosts(ctx context.Context, limit int, offset int) ([]core.Post, error) {
 	// ✅ ADD LIMIT AND OFFSET
 	query := postSelectQuery + `
+        WHERE ` + listedPost + `
         ORDER BY p.created_at DESC
         LIMIT $1 OFFSET $2`
 
@@ -261,9 +239,10 @@ func (r *postRepository) GetPosts(ctx context.Context, limit int, offset int) ([
 
 	return r.scanPosts(rows)
 }
-func (r *postRepository) GetFeedPosts(ctx context.Context, limit int, offset int, excludeUserID uuid.UUID) ([]core.Post, error) {
-	const query = `
-        SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.photo_path, p.verified, p.created_at,
+
+// feedSelectQuery expects the viewer as $1 so it can fill in their like and bookmark state.
+const feedSelectQuery = `
+        SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.latitude, p.longitude, p.photo_path, p.verified, p.draft, p.moderation_status, COALESCE(p.rejection_reason, ''), p.country_code, p.entities, p.created_at,
                u.name, COALESCE(u.profile_path, ''),
                -- 1. Check if "Bookmarked" (Favorites table)
                EXISTS(SELECT 1 FROM favorites f WHERE f.post_id = p.id AND f.user_id = $1) AS is_favorited,
@@ -273,7 +252,11 @@ func (r *postRepository) GetFeedPosts(ctx context.Context, limit int, offset int
                (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = p.id) AS like_count
         FROM posts p
         JOIN users u ON p.user_id = u.id
-        WHERE p.user_id != $1 
+`
+
+func (r *postRepository) GetFeedPosts(ctx context.Context, limit int, offset int, excludeUserID uuid.UUID) ([]core.Post, error) {
+	query := feedSelectQuery + `
+        WHERE p.user_id != $1 AND ` + listedPost + `
         ORDER BY p.created_at DESC
         LIMIT $2 OFFSET $3`
 
@@ -283,11 +266,49 @@ func (r *postRepository) GetFeedPosts(ctx context.Context, limit int, offset int
 	}
 	defer rows.Close()
 
+	return scanFeedPosts(rows)
+}
+
+// GetFollowingFeedPosts only returns posts written by people userID follows.
+func (r *postRepository) GetFollowingFeedPosts(ctx context.Context, limit int, offset int, userID uuid.UUID) ([]core.Post, error) {
+	query := feedSelectQuery + `
+        JOIN follows fo ON fo.followee_id = p.user_id AND fo.follower_id = $1
+        WHERE ` + listedPost + `
+        ORDER BY p.created_at DESC
+        LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFeedPosts(rows)
+}
+
+// GetPostsByTag lists published posts whose description carries the (normalized) hashtag.
+func (r *postRepository) GetPostsByTag(ctx context.Context, tag string, limit int, offset int, viewerID uuid.UUID) ([]core.Post, error) {
+	query := feedSelectQuery + `
+        JOIN post_hashtags ht ON ht.post_id = p.id AND ht.tag = $2
+        WHERE ` + listedPost + `
+        ORDER BY p.created_at DESC
+        LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(ctx, query, viewerID, tag, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFeedPosts(rows)
+}
+
+func scanFeedPosts(rows *sql.Rows) ([]core.Post, error) {
 	var posts []core.Post
 	for rows.Next() {
 		var p core.Post
 		if err := rows.Scan(
-			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.PhotoPath, &p.Verified, &p.CreatedAt,
+			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.Latitude, &p.Longitude, &p.PhotoPath, &p.Verified, &p.Draft, &p.ModerationStatus, &p.RejectionReason, &p.CountryCode, &p.Entities, &p.CreatedAt,
 			&p.UserName, &p.UserPhotoPath,
 			&p.IsFavorited, // Bookmark status
 			&p.IsLiked,     // Like status
@@ -298,22 +319,24 @@ func (r *postRepository) GetFeedPosts(ctx context.Context, limit int, offset int
 		posts = append(posts, p)
 	}
 
-	return posts, nil
+	return posts, rows.Err()
 }
-func (r *postRepository) ToggleLike(ctx context.Context, userID, postID uuid.UUID) error {
+
+// ToggleLike reports whether the post is liked after the toggle.
+func (r *postRepository) ToggleLike(ctx context.Context, userID, postID uuid.UUID) (bool, error) {
 	// Check if liked
 	var exists bool
 	checkQuery := "SELECT EXISTS(SELECT 1 FROM post_likes WHERE user_id = $1 AND post_id = $2)"
 	if err := r.db.QueryRowContext(ctx, checkQuery, userID, postID).Scan(&exists); err != nil {
-		return err
+		return false, err
 	}
 
 	if exists {
 		_, err := r.db.ExecContext(ctx, "DELETE FROM post_likes WHERE user_id = $1 AND post_id = $2", userID, postID)
-		return err
+		return false, err
 	} else {
 		_, err := r.db.ExecContext(ctx, "INSERT INTO post_likes (user_id, post_id) VALUES ($1, $2)", userID, postID)
-		return err
+		return err == nil, err
 	}
 }
 func (r *postRepository) IsLiked(ctx context.Context, userID, postID uuid.UUID) (bool, error) {
@@ -335,3 +358,103 @@ func (r *postRepository) GetLikeCount(postID uuid.UUID) (int64, error) {
 
 	return count, err
 }
+
+// GetNearbyPosts returns verified posts within radiusKm of (lat, lng), closest first.
+// The latitude band narrows the rows using the index; the haversine formula does the exact cut.
+func (r *postRepository) GetNearbyPosts(ctx context.Context, lat, lng, radiusKm float64, limit int) ([]core.Post, error) {
+	const query = `
+        SELECT * FROM (
+            SELECT p.id, p.user_id, p.title, p.description, p.rating, p.coordinates, p.latitude, p.longitude, p.photo_path, p.verified, p.draft, p.moderation_status, COALESCE(p.rejection_reason, ''), p.country_code, p.entities, p.created_at,
+                   u.name, COALESCE(u.profile_path, ''),
+                   6371 * 2 * ASIN(SQRT(
+                       POWER(SIN(RADIANS(p.latitude - $1) / 2), 2) +
+                       COS(RADIANS($1)) * COS(RADIANS(p.latitude)) * POWER(SIN(RADIANS(p.longitude - $2) / 2), 2)
+                   )) AS distance_km
+            FROM posts p
+            JOIN users u ON p.user_id = u.id
+            WHERE p.verified = true
+              AND p.latitude BETWEEN $3 AND $4
+              AND p.longitude IS NOT NULL
+        ) nearby
+        WHERE distance_km <= $5
+        ORDER BY distance_km ASC
+        LIMIT $6`
+
+	delta := utils.LatitudeDelta(radiusKm)
+	rows, err := r.db.QueryContext(ctx, query, lat, lng, lat-delta, lat+delta, radiusKm, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: get nearby posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []core.Post
+	for rows.Next() {
+		var p core.Post
+		var distance float64
+		if err := rows.Scan(
+			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Rating, &p.Coordinates, &p.Latitude, &p.Longitude, &p.PhotoPath, &p.Verified, &p.Draft, &p.ModerationStatus, &p.RejectionReason, &p.CountryCode, &p.Entities, &p.CreatedAt,
+			&p.UserName, &p.UserPhotoPath,
+			&distance,
+		); err != nil {
+			return nil, err
+		}
+		p.DistanceKm = &distance
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+// boundsFilter matches posts inside a viewport given as $1=minLat, $2=maxLat, $3=minLng, $4=maxLng.
+// When minLng > maxLng the box wraps around the antimeridian.
+// $3 and $4 are cast because comparing two bare parameters would type them as text.
+const boundsFilter = `
+        p.verified = true
+        AND p.latitude BETWEEN $1 AND $2
+        AND (
+            ($3::double precision <= $4::double precision AND p.longitude BETWEEN $3 AND $4)
+            OR ($3::double precision > $4::double precision AND (p.longitude >= $3 OR p.longitude <= $4))
+        )`
+
+func (r *postRepository) GetPostsInBounds(ctx context.Context, box core.BoundingBox, limit int) ([]core.Post, error) {
+	query := postSelectQuery + "WHERE" + boundsFilter + `
+        ORDER BY p.created_at DESC
+        LIMIT $5`
+
+	rows, err := r.db.QueryContext(ctx, query, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: get posts in bounds: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanPosts(rows)
+}
+
+// GetPostClusters snaps every post in the viewport to a grid of cellDegrees and
+// returns one centroid per occupied cell.
+func (r *postRepository) GetPostClusters(ctx context.Context, box core.BoundingBox, cellDegrees float64) ([]core.PostCluster, error) {
+	query := `
+        SELECT AVG(p.latitude), AVG(p.longitude), COUNT(*), (ARRAY_AGG(p.id))[1]
+        FROM posts p
+        WHERE` + boundsFilter + `
+        GROUP BY FLOOR(p.latitude / $5), FLOOR(p.longitude / $5)`
+
+	rows, err := r.db.QueryContext(ctx, query, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng, cellDegrees)
+	if err != nil {
+		return nil, fmt.Errorf("repository: get post clusters: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := []core.PostCluster{}
+	for rows.Next() {
+		var cl core.PostCluster
+		var firstID uuid.UUID
+		if err := rows.Scan(&cl.Latitude, &cl.Longitude, &cl.Count, &firstID); err != nil {
+			return nil, err
+		}
+		if cl.Count == 1 {
+			cl.PostID = &firstID
+		}
+		clusters = append(clusters, cl)
+	}
+	return clusters, rows.Err()
+}
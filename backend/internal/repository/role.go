@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrRoleUnchanged = errors.New("user already has this role")
+
+type RoleRepository interface {
+	ChangeRole(ctx context.Context, userID uuid.UUID, newRole string, changedBy uuid.UUID, reason string) (core.RoleChange, error)
+	GetRoleHistory(ctx context.Context, userID uuid.UUID) ([]core.RoleChange, error)
+	GetUsersByRole(ctx context.Context, role string) ([]core.StaffMember, error)
+}
+
+type roleRepository struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// ChangeRole updates users.role and writes the audit row in the same transaction,
+// so a role can never change without a matching entry in role_changes.
+func (r *roleRepository) ChangeRole(ctx context.Context, userID uuid.UUID, newRole string, changedBy uuid.UUID, reason string) (core.RoleChange, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return core.RoleChange{}, fmt.Errorf("repository: change role: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldRole string
+	if err := tx.GetContext(ctx, &oldRole, `SELECT role FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.RoleChange{}, sql.ErrNoRows
+		}
+		return core.RoleChange{}, fmt.Errorf("repository: change role: %w", err)
+	}
+	if oldRole == newRole {
+		return core.RoleChange{}, ErrRoleUnchanged
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`, newRole, userID); err != nil {
+		return core.RoleChange{}, fmt.Errorf("repository: change role: %w", err)
+	}
+
+	const audit = `
+        INSERT INTO role_changes (user_id, old_role, new_role, changed_by, reason)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, user_id, old_role, new_role, changed_by, COALESCE(reason, '') AS reason, created_at`
+
+	var change core.RoleChange
+	if err := tx.GetContext(ctx, &change, audit, userID, oldRole, newRole, changedBy, reason); err != nil {
+		return core.RoleChange{}, fmt.Errorf("repository: record role change: %w", err)
+	}
+
+	// Access tokens carry the role, so the user's sessions end with it and the next
+	// login picks up the new one.
+	const revoke = `
+        UPDATE sessions SET revoked_at = NOW(), revoked_reason = 'role_changed'
+        WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, revoke, userID); err != nil {
+		return core.RoleChange{}, fmt.Errorf("repository: change role: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return core.RoleChange{}, fmt.Errorf("repository: change role: %w", err)
+	}
+	return change, nil
+}
+
+func (r *roleRepository) GetRoleHistory(ctx context.Context, userID uuid.UUID) ([]core.RoleChange, error) {
+	const query = `
+        SELECT id, user_id, old_role, new_role, changed_by, COALESCE(reason, '') AS reason, created_at
+        FROM role_changes
+        WHERE user_id = $1
+        ORDER BY created_at DESC`
+
+	history := []core.RoleChange{}
+	if err := r.db.SelectContext(ctx, &history, query, userID); err != nil {
+		return nil, fmt.Errorf("repository: get role history: %w", err)
+	}
+	return history, nil
+}
+
+func (r *roleRepository) GetUsersByRole(ctx context.Context, role string) ([]core.StaffMember, error) {
+	const query = `
+        SELECT id, name, surname, email, role
+        FROM users
+        WHERE role = $1
+        ORDER BY name, surname`
+
+	users := []core.StaffMember{}
+	if err := r.db.SelectContext(ctx, &users, query, role); err != nil {
+		return nil, fmt.Errorf("repository: get users by role: %w", err)
+	}
+	return users, nil
+}
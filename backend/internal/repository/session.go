@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session core.Session, tokenHash string) (core.Session, error)
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, ttl time.Duration) (core.Session, error)
+	RevokeByTokenHash(ctx context.Context, tokenHash, reason string) error
+	GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]core.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, reason string) error
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID, reason string) (int64, error)
+	IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+type sessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+const sessionColumns = `s.id, s.user_id, COALESCE(s.user_agent, '') AS user_agent, COALESCE(s.ip_address, '') AS ip_address,
+       s.created_at, s.last_seen_at, s.expires_at, s.revoked_at`
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session core.Session, tokenHash string) (core.Session, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return core.Session{}, fmt.Errorf("repository: create session: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertSession = `
+        INSERT INTO sessions AS s (user_id, user_agent, ip_address, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + sessionColumns
+
+	var created core.Session
+	if err := tx.GetContext(ctx, &created, insertSession, session.UserID, session.UserAgent, session.IPAddress, session.ExpiresAt); err != nil {
+		return core.Session{}, fmt.Errorf("repository: create session: %w", err)
+	}
+
+	const insertToken = `INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, insertToken, created.ID, tokenHash, session.ExpiresAt); err != nil {
+		return core.Session{}, fmt.Errorf("repository: create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return core.Session{}, fmt.Errorf("repository: create session: %w", err)
+	}
+	return created, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same session.
+// Presenting an already-rotated token revokes the session and returns ErrRefreshTokenReused.
+func (r *sessionRepository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, ttl time.Duration) (core.Session, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return core.Session{}, fmt.Errorf("repository: rotate refresh token: %w", err)
+	}
+	defer tx.Rollback()
+
+	const lookup = `
+        SELECT ` + sessionColumns + `,
+               rt.id AS token_id, rt.used_at AS token_used_at, rt.expires_at AS token_expires_at
+        FROM refresh_tokens rt
+        JOIN sessions s ON rt.session_id = s.id
+        WHERE rt.token_hash = $1
+        FOR UPDATE OF rt, s`
+
+	var row struct {
+		core.Session
+		TokenID        uuid.UUID  `db:"token_id"`
+		TokenUsedAt    *time.Time `db:"token_used_at"`
+		TokenExpiresAt time.Time  `db:"token_expires_at"`
+	}
+	if err := tx.GetContext(ctx, &row, lookup, oldHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Session{}, ErrRefreshTokenInvalid
+		}
+		return core.Session{}, fmt.Errorf("repository: rotate refresh token: %w", err)
+	}
+	session := row.Session
+
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return core.Session{}, ErrRefreshTokenInvalid
+	}
+
+	if row.TokenUsedAt != nil {
+		const revoke = `UPDATE sessions SET revoked_at = NOW(), revoked_reason = 'token_reuse' WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, revoke, session.ID); err != nil {
+			return core.Session{}, fmt.Errorf("repository: revoke reused session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return core.Session{}, fmt.Errorf("repository: revoke reused session: %w", err)
+		}
+		return core.Session{}, ErrRefreshTokenReused
+	}
+
+	if now.After(row.TokenExpiresAt) {
+		return core.Session{}, ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, row.TokenID); err != nil {
+		return core.Session{}, fmt.Errorf("repository: rotate refresh token: %w", err)
+	}
+
+	expiresAt := now.Add(ttl)
+	const insertToken = `INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, insertToken, session.ID, newHash, expiresAt); err != nil {
+		return core.Session{}, fmt.Errorf("repository: rotate refresh token: %w", err)
+	}
+
+	const touch = `UPDATE sessions SET last_seen_at = NOW(), expires_at = $1 WHERE id = $2`
+	if _, err := tx.ExecContext(ctx, touch, expiresAt, session.ID); err != nil {
+		return core.Session{}, fmt.Errorf("repository: rotate refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return core.Session{}, fmt.Errorf("repository: rotate refresh token: %w", err)
+	}
+
+	session.LastSeenAt = now
+	session.ExpiresAt = expiresAt
+	return session, nil
+}
+
+func (r *sessionRepository) RevokeByTokenHash(ctx context.Context, tokenHash, reason string) error {
+	const query = `
+        UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2
+        WHERE revoked_at IS NULL
+          AND id = (SELECT session_id FROM refresh_tokens WHERE token_hash = $1)`
+
+	if _, err := r.db.ExecContext(ctx, query, tokenHash, reason); err != nil {
+		return fmt.Errorf("repository: revoke session: %w", err)
+	}
+	return nil
+}
+
+func (r *sessionRepository) GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]core.Session, error) {
+	const query = `
+        SELECT ` + sessionColumns + `
+        FROM sessions s
+        WHERE s.user_id = $1 AND s.revoked_at IS NULL AND s.expires_at > NOW()
+        ORDER BY s.last_seen_at DESC`
+
+	sessions := []core.Session{}
+	if err := r.db.SelectContext(ctx, &sessions, query, userID); err != nil {
+		return nil, fmt.Errorf("repository: get active sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession only touches sessions owned by userID and returns sql.ErrNoRows otherwise.
+func (r *sessionRepository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, reason string) error {
+	const query = `
+        UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, sessionID, userID, reason)
+	if err != nil {
+		return fmt.Errorf("repository: revoke session: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RevokeOtherSessions revokes every active session of the user except keepSessionID.
+// Pass uuid.Nil to revoke all of them.
+func (r *sessionRepository) RevokeOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID, reason string) (int64, error) {
+	const query = `
+        UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+        WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, userID, keepSessionID, reason)
+	if err != nil {
+		return 0, fmt.Errorf("repository: revoke other sessions: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows, nil
+}
+
+// IsSessionActive reports whether access tokens issued for the session may still be used.
+func (r *sessionRepository) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	const query = `
+        SELECT EXISTS(
+            SELECT 1 FROM sessions
+            WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+        )`
+
+	var active bool
+	if err := r.db.GetContext(ctx, &active, query, sessionID); err != nil {
+		return false, fmt.Errorf("repository: check session: %w", err)
+	}
+	return active, nil
+}
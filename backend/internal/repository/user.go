@@ -5,20 +5,28 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/TeamA166/WonderTrip/internal/core"
+	"github.com/TeamA166/WonderTrip/internal/utils"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+var ErrUsernameTaken = errors.New("username is already taken")
+
 type UserRepository interface {
 	CreateUser(ctx context.Context, user core.User) (core.User, error)
 	GetByEmail(ctx context.Context, email string) (core.User, error)
 	GetById(ctx context.Context, uuid uuid.UUID) (core.User, error)
 	UpdateUser(ctx context.Context, user core.User) error
+	UpdateProfile(ctx context.Context, user core.User) error
 	UpdateProfilePhoto(ctx context.Context, userID uuid.UUID, path string) error
 	UpdatePassword(ctx context.Context, userID uuid.UUID, newHash string) error
 	GetByIdForPassword(ctx context.Context, uuid uuid.UUID) (core.User, error)
+	IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+	GetPublicProfile(ctx context.Context, userID uuid.UUID) (core.PublicProfile, error)
 }
 
 type userRepository struct {
@@ -29,25 +37,38 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// CreateUser picks a username from the user's name when none is given,
+// adding a random suffix until it is unique.
 func (r *userRepository) CreateUser(ctx context.Context, user core.User) (core.User, error) {
 	const query = `
-		INSERT INTO users (email, password_hash, name, surname, profile_path)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, email, name, surname, password_hash, created_at, updated_at`
+		INSERT INTO users (email, password_hash, name, surname, profile_path, username)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, email, name, surname, username, COALESCE(role, 'user') AS role, password_hash, email_verified_at, created_at, updated_at`
 
 	var created core.User
 
 	const profilePath = "uploads/profile/Default_pfp.jpg"
-	if err := r.db.GetContext(ctx, &created, query, user.Email, user.PasswordHash, user.Name, user.Surname, profilePath); err != nil {
-		return core.User{}, fmt.Errorf("repository: create user: %w", err)
+	base := user.Username
+	if base == "" {
+		base = utils.UsernameBase(user.Name, user.Surname)
 	}
 
-	return created, nil
+	username := base
+	for attempt := 0; ; attempt++ {
+		err := r.db.GetContext(ctx, &created, query, user.Email, user.PasswordHash, user.Name, user.Surname, profilePath, username)
+		if err == nil {
+			return created, nil
+		}
+		if !isUniqueViolation(err, "users_username_key") || attempt == 5 {
+			return core.User{}, fmt.Errorf("repository: create user: %w", err)
+		}
+		username = utils.UsernameWithSuffix(base)
+	}
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (core.User, error) {
 	const query = `
-		SELECT id, email, name, surname, password_hash, created_at, updated_at
+		SELECT id, email, name, surname, username, COALESCE(role, 'user') AS role, password_hash, email_verified_at, created_at, updated_at
 		FROM users
 		WHERE email = $1
 		LIMIT 1`
@@ -64,7 +85,8 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (core.Use
 }
 func (r *userRepository) GetById(ctx context.Context, uuid uuid.UUID) (core.User, error) {
 	const query = `
-	SELECT id, email, name, surname, profile_path
+	SELECT id, email, name, surname, username, COALESCE(role, 'user') AS role, profile_path, email_verified_at,
+	       COALESCE(bio, '') AS bio, COALESCE(home_city, '') AS home_city, COALESCE(website, '') AS website, gender
 	FROM users
 	WHERE id = $1
 	LIMIT 1`
@@ -87,6 +109,25 @@ func (r *userRepository) UpdateUser(ctx context.Context, user core.User) error {
 	}
 	return nil
 }
+
+// UpdateProfile writes the fields a user edits on their profile; empty optional fields are stored as NULL.
+// It returns ErrUsernameTaken if another user already has the username.
+func (r *userRepository) UpdateProfile(ctx context.Context, user core.User) error {
+	const query = `
+        UPDATE users
+        SET name = $1, surname = $2, bio = NULLIF($3, ''), home_city = NULLIF($4, ''), website = NULLIF($5, ''),
+            gender = $6, username = $7, updated_at = NOW()
+        WHERE id = $8`
+
+	_, err := r.db.ExecContext(ctx, query, user.Name, user.Surname, user.Bio, user.HomeCity, user.Website, user.Gender, user.Username, user.ID)
+	if err != nil {
+		if isUniqueViolation(err, "users_username_key") {
+			return ErrUsernameTaken
+		}
+		return fmt.Errorf("repository: update profile: %w", err)
+	}
+	return nil
+}
 func (r *userRepository) UpdateProfilePhoto(ctx context.Context, userID uuid.UUID, path string) error {
 	const query = `UPDATE users SET profile_path = $1 WHERE id = $2`
 	_, err := r.db.ExecContext(ctx, query, path, userID)
@@ -106,3 +147,80 @@ func (r *userRepository) GetByIdForPassword(ctx context.Context, uuid uuid.UUID)
 	}
 	return user, nil
 }
+
+func (r *userRepository) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	const query = `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`
+
+	var verified bool
+	if err := r.db.GetContext(ctx, &verified, query, userID); err != nil {
+		return false, fmt.Errorf("repository: is email verified: %w", err)
+	}
+	return verified, nil
+}
+
+// GetPublicProfile returns sql.ErrNoRows for unknown users. Follow counts are filled in by the caller.
+func (r *userRepository) GetPublicProfile(ctx context.Context, userID uuid.UUID) (core.PublicProfile, error) {
+	// Ratings of 0 mean "not rated" and are left out of the average.
+	const query = `
+        SELECT u.id, u.name, u.surname, u.username, COALESCE(u.profile_path, '') AS profile_path, COALESCE(u.bio, '') AS bio,
+               COALESCE(u.home_city, '') AS home_city, COALESCE(u.website, '') AS website, u.created_at,
+               COUNT(p.id) AS posts,
+               COUNT(p.id) FILTER (WHERE p.verified) AS verified_posts,
+               COALESCE(SUM(pl.likes), 0) AS likes_received,
+               ROUND(AVG(NULLIF(p.rating, 0))::numeric, 2)::float8 AS average_rating,
+               COALESCE(array_agg(DISTINCT p.country_code::text) FILTER (WHERE p.country_code IS NOT NULL), '{}') AS countries
+        FROM users u
+        LEFT JOIN posts p ON p.user_id = u.id AND ` + listedPost + `
+        LEFT JOIN LATERAL (SELECT COUNT(*) AS likes FROM post_likes WHERE post_id = p.id) pl ON true
+        WHERE u.id = $1
+        GROUP BY u.id`
+
+	var row struct {
+		ID            uuid.UUID      `db:"id"`
+		Name          string         `db:"name"`
+		Surname       string         `db:"surname"`
+		Username      string         `db:"username"`
+		ProfilePath   string         `db:"profile_path"`
+		Bio           string         `db:"bio"`
+		HomeCity      string         `db:"home_city"`
+		Website       string         `db:"website"`
+		CreatedAt     time.Time      `db:"created_at"`
+		Posts         int            `db:"posts"`
+		VerifiedPosts int            `db:"verified_posts"`
+		LikesReceived int            `db:"likes_received"`
+		AverageRating *float64       `db:"average_rating"`
+		Countries     pq.StringArray `db:"countries"`
+	}
+	if err := r.db.GetContext(ctx, &row, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.PublicProfile{}, sql.ErrNoRows
+		}
+		return core.PublicProfile{}, fmt.Errorf("repository: get public profile: %w", err)
+	}
+
+	return core.PublicProfile{
+		ID:          row.ID,
+		Name:        row.Name,
+		Surname:     row.Surname,
+		Username:    row.Username,
+		ProfilePath: row.ProfilePath,
+		Bio:         row.Bio,
+		HomeCity:    row.HomeCity,
+		Website:     row.Website,
+		JoinedAt:    row.CreatedAt,
+		Stats: core.UserStats{
+			Posts:            row.Posts,
+			VerifiedPosts:    row.VerifiedPosts,
+			LikesReceived:    row.LikesReceived,
+			AverageRating:    row.AverageRating,
+			CountriesVisited: len(row.Countries),
+			Countries:        []string(row.Countries),
+		},
+	}, nil
+}
+
+// isUniqueViolation reports whether err is a unique_violation on the named constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
@@ -0,0 +1,157 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TeamA166/WonderTrip/internal/config"
+)
+
+type KeyStatus string
+
+const (
+	// StatusActive signs new tokens. Exactly one key must be active.
+	StatusActive KeyStatus = "active"
+	// StatusVerify only verifies: the next key before it is promoted, or the previous one until its tokens expire.
+	StatusVerify KeyStatus = "verify"
+	// StatusRetired keys are ignored; tokens carrying their kid are rejected.
+	StatusRetired KeyStatus = "retired"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+// LegacyKeyID is assumed for tokens issued before keys had ids (no kid header).
+const LegacyKeyID = "legacy"
+
+type Key struct {
+	ID        string
+	Algorithm string
+	Status    KeyStatus
+	// signKey is a crypto.Signer for asymmetric keys and []byte for HS256.
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeysFromConfig loads auth.signing_keys. Without any configured keys the old auth.jwt_secret
+// becomes an active HS256 key; with configured keys it stays accepted for verification so
+// tokens issued before the switch keep working until they expire.
+func KeysFromConfig(cfg config.AuthConfig) ([]Key, error) {
+	var keys []Key
+	for _, kc := range cfg.SigningKeys {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", kc.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if cfg.JWTSecret != "" && !hasKey(keys, LegacyKeyID) {
+		status := StatusVerify
+		if len(keys) == 0 {
+			status = StatusActive
+		}
+		secret := []byte(cfg.JWTSecret)
+		keys = append(keys, Key{ID: LegacyKeyID, Algorithm: AlgHS256, Status: status, signKey: secret, verifyKey: secret})
+	}
+	return keys, nil
+}
+
+func loadKey(kc config.SigningKey) (Key, error) {
+	if kc.ID == "" {
+		return Key{}, errors.New("id is required")
+	}
+
+	status := KeyStatus(strings.ToLower(kc.Status))
+	switch status {
+	case StatusActive, StatusVerify, StatusRetired:
+	case "":
+		status = StatusVerify
+	default:
+		return Key{}, fmt.Errorf("unknown status %q", kc.Status)
+	}
+	key := Key{ID: kc.ID, Algorithm: kc.Algorithm, Status: status}
+
+	if key.Algorithm == AlgHS256 {
+		if kc.Secret == "" {
+			return Key{}, errors.New("HS256 keys need a secret")
+		}
+		key.signKey, key.verifyKey = []byte(kc.Secret), []byte(kc.Secret)
+		return key, nil
+	}
+	if status == StatusRetired {
+		return key, nil
+	}
+
+	pemData := []byte(kc.PrivateKey)
+	if kc.PrivateKeyFile != "" {
+		data, err := os.ReadFile(kc.PrivateKeyFile)
+		if err != nil {
+			return Key{}, err
+		}
+		pemData = data
+	}
+	signer, err := parsePrivateKey(pemData)
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch k := signer.(type) {
+	case *rsa.PrivateKey:
+		if key.Algorithm != AlgRS256 {
+			return Key{}, fmt.Errorf("RSA key cannot be used for %q", key.Algorithm)
+		}
+		key.verifyKey = &k.PublicKey
+	case ed25519.PrivateKey:
+		if key.Algorithm != AlgEdDSA {
+			return Key{}, fmt.Errorf("Ed25519 key cannot be used for %q", key.Algorithm)
+		}
+		key.verifyKey = k.Public()
+	default:
+		return Key{}, errors.New("unsupported private key type")
+	}
+	key.signKey = signer
+	return key, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM private key found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := k.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		return signer, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+func hasKey(keys []Key, id string) bool {
+	for _, k := range keys {
+		if k.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// Package tokens issues and verifies the JWTs used by the API (access, mfa_pending, password_reset, photo).
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	TypeAccess        = "access"
+	TypeMFAPending    = "mfa_pending"
+	TypePasswordReset = "password_reset"
+	// TypePhoto grants read access to a single post photo, for links handed to other tools.
+	TypePhoto = "photo"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+type Signer struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+func NewSigner(keys []Key) (*Signer, error) {
+	s := &Signer{keys: make(map[string]*Key, len(keys))}
+	for i := range keys {
+		k := &keys[i]
+		if _, dup := s.keys[k.ID]; dup {
+			return nil, fmt.Errorf("tokens: duplicate key id %q", k.ID)
+		}
+		s.keys[k.ID] = k
+		if k.Status == StatusActive {
+			if s.active != nil {
+				return nil, fmt.Errorf("tokens: keys %q and %q are both active", s.active.ID, k.ID)
+			}
+			s.active = k
+		}
+	}
+	if s.active == nil {
+		return nil, errors.New("tokens: no active signing key configured")
+	}
+	return s, nil
+}
+
+// Sign issues a token of the given type with the active key and stamps its kid.
+func (s *Signer) Sign(tokenType string, claims jwt.MapClaims) (string, error) {
+	claims["type"] = tokenType
+
+	token := jwt.NewWithClaims(signingMethod(s.active.Algorithm), claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.signKey)
+}
+
+// Parse verifies a token against any non-retired key and checks its type.
+// The algorithm must match the key it names, so an RSA public key can never be used as an HMAC secret.
+func (s *Signer) Parse(tokenString, tokenType string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			kid = LegacyKeyID
+		}
+		key, ok := s.keys[kid]
+		if !ok || key.Status == StatusRetired || key.verifyKey == nil {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if t.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method %q", t.Method.Alg())
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims["type"] != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	switch alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS lists the public half of every usable asymmetric key. HS256 secrets are never published.
+func (s *Signer) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, k := range s.keys {
+		if k.Status == StatusRetired {
+			continue
+		}
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "RSA", Kid: k.ID, Use: "sig", Alg: k.Algorithm,
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "OKP", Kid: k.ID, Use: "sig", Alg: k.Algorithm, Crv: "Ed25519",
+				X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+)
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 30
+	MaxHashtagLength  = 50
+)
+
+var ErrInvalidUsername = errors.New("username must be 3-30 characters of a-z, 0-9 and _")
+var ErrInvalidHashtag = errors.New("tag must be 1-50 letters, digits or _ and contain a letter")
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// usernameFold maps the letters of Turkish names that have an obvious ASCII spelling.
+var usernameFold = strings.NewReplacer(
+	"ç", "c", "ğ", "g", "ı", "i", "ö", "o", "ş", "s", "ü", "u",
+	"Ç", "c", "Ğ", "g", "İ", "i", "Ö", "o", "Ş", "s", "Ü", "u",
+)
+
+// NormalizeUsername lower-cases value, drops a leading @ and checks the allowed alphabet.
+func NormalizeUsername(value string) (string, error) {
+	username := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "@"))
+	if !usernamePattern.MatchString(username) {
+		return "", ErrInvalidUsername
+	}
+	return username, nil
+}
+
+// UsernameBase suggests a username from a person's name. The result is always valid
+// but may be taken; callers append a suffix until it is unique.
+func UsernameBase(name, surname string) string {
+	folded := strings.ToLower(usernameFold.Replace(name + surname))
+
+	var b strings.Builder
+	for _, r := range folded {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		}
+		// Leave room for a numeric suffix.
+		if b.Len() == 20 {
+			break
+		}
+	}
+
+	base := b.String()
+	if len(base) < MinUsernameLength {
+		base = "traveler" + base
+	}
+	return base
+}
+
+// UsernameWithSuffix appends a random number to a taken username, keeping it within the length limit.
+func UsernameWithSuffix(base string) string {
+	suffix := fmt.Sprintf("%d", 1000+rand.IntN(9000))
+	if len(base)+len(suffix) > MaxUsernameLength {
+		base = base[:MaxUsernameLength-len(suffix)]
+	}
+	return base + suffix
+}
+
+// NormalizeHashtag lower-cases value and drops a leading #.
+func NormalizeHashtag(value string) (string, error) {
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "#"))
+
+	runes := []rune(tag)
+	if len(runes) == 0 || len(runes) > MaxHashtagLength {
+		return "", ErrInvalidHashtag
+	}
+	hasLetter := false
+	for _, r := range runes {
+		if !isTagRune(r) {
+			return "", ErrInvalidHashtag
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+	}
+	if !hasLetter {
+		return "", ErrInvalidHashtag
+	}
+	return tag, nil
+}
+
+// ParseEntities finds @mentions and #hashtags in text. A marker only counts at the
+// start of the text or after a character that cannot be part of a word, so email
+// addresses and URL fragments are left alone. Mentions are returned without a UserID;
+// resolving usernames is up to the caller.
+func ParseEntities(text string) core.TextEntities {
+	runes := []rune(text)
+	entities := core.TextEntities{}
+
+	offset := 0 // UTF-16 position of runes[i]
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if (r != '@' && r != '#') || (i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '&' || runes[i-1] == '@' || runes[i-1] == '#')) {
+			offset += utf16Len(r)
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(runes) && isTagRune(runes[j]) {
+			j++
+		}
+
+		raw := string(runes[i:j])
+		length := 0
+		for _, c := range runes[i:j] {
+			length += utf16Len(c)
+		}
+
+		entity := core.TextEntity{Start: offset, End: offset + length}
+		var err error
+		if r == '@' {
+			entity.Type = core.EntityMention
+			entity.Value, err = NormalizeUsername(raw)
+		} else {
+			entity.Type = core.EntityHashtag
+			entity.Value, err = NormalizeHashtag(raw)
+		}
+		if err == nil {
+			entities = append(entities, entity)
+		}
+
+		offset += length
+		i = j
+	}
+	return entities
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func utf16Len(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+)
+
+const earthRadiusKm = 6371.0
+
+var ErrInvalidCoordinates = errors.New("Coordinates must be in 'lat,lng' format with valid ranges")
+var ErrInvalidBoundingBox = errors.New("bbox must be 'minLng,minLat,maxLng,maxLat' with valid ranges")
+var ErrInvalidCountryCode = errors.New("country_code must be a two-letter ISO 3166-1 code")
+
+// ParseCountryCode upper-cases an ISO 3166-1 alpha-2 code. An empty value means "unknown" and yields nil.
+func ParseCountryCode(value string) (*string, error) {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if code == "" {
+		return nil, nil
+	}
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return nil, ErrInvalidCountryCode
+	}
+	return &code, nil
+}
+
+// ParseCoordinates turns the "lat,lng" string sent by the app into numbers.
+func ParseCoordinates(value string) (float64, float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, ErrInvalidCoordinates
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCoordinates
+	}
+
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCoordinates
+	}
+
+	if !ValidLatLng(lat, lng) {
+		return 0, 0, ErrInvalidCoordinates
+	}
+
+	return lat, lng, nil
+}
+
+// ParseBoundingBox parses a "minLng,minLat,maxLng,maxLat" viewport.
+// minLng may be greater than maxLng when the viewport crosses the antimeridian.
+func ParseBoundingBox(value string) (core.BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return core.BoundingBox{}, ErrInvalidBoundingBox
+	}
+
+	var nums [4]float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return core.BoundingBox{}, ErrInvalidBoundingBox
+		}
+		nums[i] = n
+	}
+
+	box := core.BoundingBox{MinLng: nums[0], MinLat: nums[1], MaxLng: nums[2], MaxLat: nums[3]}
+	if !ValidLatLng(box.MinLat, box.MinLng) || !ValidLatLng(box.MaxLat, box.MaxLng) || box.MinLat > box.MaxLat {
+		return core.BoundingBox{}, ErrInvalidBoundingBox
+	}
+
+	return box, nil
+}
+
+func ValidLatLng(lat, lng float64) bool {
+	if math.IsNaN(lat) || math.IsNaN(lng) {
+		return false
+	}
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// LatitudeDelta is how many degrees of latitude cover the given distance.
+// Used to pre-filter rows on the indexed column before the exact distance check.
+func LatitudeDelta(km float64) float64 {
+	return km / (earthRadiusKm * math.Pi / 180)
+}
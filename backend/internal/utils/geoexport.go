@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+)
+
+type GeoFormat string
+
+const (
+	FormatGeoJSON GeoFormat = "geojson"
+	FormatGPX     GeoFormat = "gpx"
+	FormatKML     GeoFormat = "kml"
+)
+
+// gpxExtensionNS carries the fields GPX has no element for (rating, verified).
+const gpxExtensionNS = "https://wondertrip.app/xmlns/gpx/1"
+
+var ErrUnsupportedFormat = errors.New("format must be one of geojson, gpx, kml")
+
+func ParseGeoFormat(value string) (GeoFormat, error) {
+	switch GeoFormat(strings.ToLower(strings.TrimSpace(value))) {
+	case FormatGeoJSON, "json", "":
+		return FormatGeoJSON, nil
+	case FormatGPX:
+		return FormatGPX, nil
+	case FormatKML:
+		return FormatKML, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+func (f GeoFormat) ContentType() string {
+	switch f {
+	case FormatGPX:
+		return "application/gpx+xml"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	default:
+		return "application/geo+json"
+	}
+}
+
+func (f GeoFormat) Extension() string {
+	return string(f)
+}
+
+// WritePlaces encodes posts one by one so large exports never sit fully rendered in memory.
+// Posts without parsed coordinates cannot be placed on a map and are skipped.
+// photoURL turns a stored photo path into a link the other tool can open.
+func WritePlaces(w io.Writer, format GeoFormat, title string, posts []core.Post, photoURL func(core.Post) string) error {
+	switch format {
+	case FormatGPX:
+		return writeGPX(w, title, posts, photoURL)
+	case FormatKML:
+		return writeKML(w, title, posts, photoURL)
+	default:
+		return writeGeoJSON(w, posts, photoURL)
+	}
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func writeGeoJSON(w io.Writer, posts []core.Post, photoURL func(core.Post) string) error {
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return err
+	}
+
+	first := true
+	for _, p := range posts {
+		if p.Latitude == nil || p.Longitude == nil {
+			continue
+		}
+
+		feature := geoJSONFeature{
+			Type: "Feature",
+			ID:   p.ID.String(),
+			// GeoJSON positions are [longitude, latitude].
+			Geometry: geoJSONPoint{Type: "Point", Coordinates: []float64{*p.Longitude, *p.Latitude}},
+			Properties: map[string]interface{}{
+				"title":       p.Title,
+				"description": p.Description,
+				"rating":      p.Rating,
+				"photo_url":   photoURL(p),
+				"verified":    p.Verified,
+				"created_at":  p.CreatedAt.UTC().Format(time.RFC3339),
+			},
+		}
+
+		encoded, err := json.Marshal(feature)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]}")
+	return err
+}
+
+type gpxWaypoint struct {
+	XMLName    xml.Name       `xml:"wpt"`
+	Lat        float64        `xml:"lat,attr"`
+	Lon        float64        `xml:"lon,attr"`
+	Time       string         `xml:"time,omitempty"`
+	Name       string         `xml:"name"`
+	Desc       string         `xml:"desc,omitempty"`
+	Link       *gpxLink       `xml:"link,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions,omitempty"`
+}
+
+type gpxLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:"text,omitempty"`
+}
+
+type gpxExtensions struct {
+	Rating   *int  `xml:"wt:rating,omitempty"`
+	Verified *bool `xml:"wt:verified,omitempty"`
+}
+
+func writeGPX(w io.Writer, title string, posts []core.Post, photoURL func(core.Post) string) error {
+	header := xml.Header + fmt.Sprintf(
+		`<gpx version="1.1" creator="WonderTrip" xmlns="http://www.topografix.com/GPX/1/1" xmlns:wt="%s"><metadata><name>%s</name></metadata>`,
+		gpxExtensionNS, escapeXML(title))
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	for _, p := range posts {
+		if p.Latitude == nil || p.Longitude == nil {
+			continue
+		}
+
+		rating, verified := p.Rating, p.Verified
+		wpt := gpxWaypoint{
+			Lat:        *p.Latitude,
+			Lon:        *p.Longitude,
+			Time:       p.CreatedAt.UTC().Format(time.RFC3339),
+			Name:       p.Title,
+			Desc:       p.Description,
+			Extensions: &gpxExtensions{Rating: &rating, Verified: &verified},
+		}
+		if url := photoURL(p); url != "" {
+			wpt.Link = &gpxLink{Href: url, Text: "Photo"}
+		}
+
+		if err := enc.Encode(wpt); err != nil {
+			return err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "</gpx>")
+	return err
+}
+
+type kmlPlacemark struct {
+	XMLName      xml.Name     `xml:"Placemark"`
+	ID           string       `xml:"id,attr"`
+	Name         string       `xml:"name"`
+	Description  string       `xml:"description,omitempty"`
+	TimeStamp    kmlTimeStamp `xml:"TimeStamp"`
+	ExtendedData []kmlData    `xml:"ExtendedData>Data"`
+	Point        kmlPoint     `xml:"Point"`
+}
+
+type kmlTimeStamp struct {
+	When string `xml:"when"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+func writeKML(w io.Writer, title string, posts []core.Post, photoURL func(core.Post) string) error {
+	header := xml.Header + fmt.Sprintf(
+		`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>%s</name>`, escapeXML(title))
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	for _, p := range posts {
+		if p.Latitude == nil || p.Longitude == nil {
+			continue
+		}
+
+		placemark := kmlPlacemark{
+			ID:          p.ID.String(),
+			Name:        p.Title,
+			Description: p.Description,
+			TimeStamp:   kmlTimeStamp{When: p.CreatedAt.UTC().Format(time.RFC3339)},
+			ExtendedData: []kmlData{
+				{Name: "rating", Value: strconv.Itoa(p.Rating)},
+				{Name: "photo_url", Value: photoURL(p)},
+				{Name: "verified", Value: strconv.FormatBool(p.Verified)},
+			},
+			// KML coordinates are "lng,lat[,alt]".
+			Point: kmlPoint{Coordinates: fmt.Sprintf("%s,%s",
+				strconv.FormatFloat(*p.Longitude, 'f', -1, 64),
+				strconv.FormatFloat(*p.Latitude, 'f', -1, 64))},
+		}
+
+		if err := enc.Encode(placemark); err != nil {
+			return err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "</Document></kml>")
+	return err
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
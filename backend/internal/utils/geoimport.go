@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/TeamA166/WonderTrip/internal/core"
+)
+
+// ParsePlaces reads every waypoint (GPX) or feature (GeoJSON) from r.
+// Only a malformed document returns an error; bad individual items come back with Problem set
+// so the caller can report them without dropping the rest of the file.
+func ParsePlaces(r io.Reader, format GeoFormat) ([]core.ImportedPlace, error) {
+	switch format {
+	case FormatGeoJSON:
+		return parseGeoJSONPlaces(r)
+	case FormatGPX:
+		return parseGPXPlaces(r)
+	}
+	return nil, fmt.Errorf("import from %s is not supported", format)
+}
+
+type geoJSONInput struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Type     string `json:"type"`
+		Geometry *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"features"`
+}
+
+func parseGeoJSONPlaces(r io.Reader) ([]core.ImportedPlace, error) {
+	var doc geoJSONInput
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if doc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("invalid GeoJSON: expected a FeatureCollection")
+	}
+
+	places := make([]core.ImportedPlace, 0, len(doc.Features))
+	for _, f := range doc.Features {
+		place := core.ImportedPlace{
+			Title:       firstStringProp(f.Properties, "title", "name"),
+			Description: firstStringProp(f.Properties, "description", "desc"),
+		}
+		rating, ratingOK := intProp(f.Properties, "rating")
+		place.Rating = rating
+
+		var position []float64
+		switch {
+		case !ratingOK:
+			place.Problem = "Rating must be a whole number"
+		case f.Geometry == nil || f.Geometry.Type != "Point":
+			place.Problem = "Only Point geometries can be imported"
+		case json.Unmarshal(f.Geometry.Coordinates, &position) != nil || len(position) < 2:
+			place.Problem = "Point coordinates are malformed"
+		default:
+			// GeoJSON positions are [longitude, latitude].
+			place.Longitude, place.Latitude = position[0], position[1]
+		}
+
+		places = append(places, place)
+	}
+
+	return places, nil
+}
+
+type gpxInput struct {
+	Waypoints []struct {
+		Lat    string `xml:"lat,attr"`
+		Lon    string `xml:"lon,attr"`
+		Name   string `xml:"name"`
+		Desc   string `xml:"desc"`
+		Cmt    string `xml:"cmt"`
+		Rating *int   `xml:"extensions>rating"`
+	} `xml:"wpt"`
+}
+
+func parseGPXPlaces(r io.Reader) ([]core.ImportedPlace, error) {
+	var doc gpxInput
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid GPX: %w", err)
+	}
+
+	places := make([]core.ImportedPlace, 0, len(doc.Waypoints))
+	for _, w := range doc.Waypoints {
+		place := core.ImportedPlace{
+			Title:       w.Name,
+			Description: w.Desc,
+			Rating:      w.Rating,
+		}
+		if place.Description == "" {
+			place.Description = w.Cmt
+		}
+
+		lat, lng, err := ParseCoordinates(w.Lat + "," + w.Lon)
+		if err != nil {
+			place.Problem = err.Error()
+		} else {
+			place.Latitude, place.Longitude = lat, lng
+		}
+
+		places = append(places, place)
+	}
+
+	return places, nil
+}
+
+func firstStringProp(props map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := props[key].(string); ok && strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// intProp reports false only when the property is present but not a whole number.
+func intProp(props map[string]interface{}, key string) (*int, bool) {
+	raw, present := props[key]
+	if !present || raw == nil {
+		return nil, true
+	}
+	v, ok := raw.(float64)
+	if !ok || v != math.Trunc(v) {
+		return nil, false
+	}
+	n := int(v)
+	return &n, true
+}
@@ -7,7 +7,7 @@ import (
 	"math/big"
 	"time"
 
-	"github.com/TeamA166/WonderTrip/internal/config"
+	"github.com/TeamA166/WonderTrip/internal/tokens"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -34,50 +34,17 @@ func VerifyHash(hashedCode, inputCode string) bool {
 }
 
 // 4. Generate Temporary Reset Token (JWT)
-func GenerateResetToken(email string) (string, error) {
-	// Load config to get the secret
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return "", fmt.Errorf("failed to load config: %w", err) // Return error, don't crash
-	}
-
-	claims := jwt.MapClaims{
+func GenerateResetToken(signer *tokens.Signer, email string) (string, error) {
+	return signer.Sign(tokens.TypePasswordReset, jwt.MapClaims{
 		"email": email,
-		"type":  "password_reset", // specific scope
 		"exp":   time.Now().Add(5 * time.Minute).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	return token.SignedString([]byte(cfg.Password.JWTSecret))
+	})
 }
 
-func ValidateResetToken(tokenString string) (string, error) {
-
-	cfg, err := config.LoadConfig()
+func ValidateResetToken(signer *tokens.Signer, tokenString string) (string, error) {
+	claims, err := signer.Parse(tokenString, tokens.TypePasswordReset)
 	if err != nil {
-		return "", fmt.Errorf("failed to load config: %w", err)
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(cfg.Password.JWTSecret), nil
-	})
-
-	if err != nil || !token.Valid {
-		return "", errors.New("invalid or expired token")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", errors.New("invalid token claims")
-	}
-
-	if claims["type"] != "password_reset" {
-		return "", errors.New("invalid token type")
+		return "", err
 	}
 
 	email, ok := claims["email"].(string)
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const defaultProfilePhoto = "uploads/profile/Default_pfp.jpg"
+
+// IsUserUpload reports whether path is a file a user uploaded (post or profile photo).
+// The shared default avatar and anything outside the upload folders are never user files.
+func IsUserUpload(path string) bool {
+	clean := filepath.ToSlash(filepath.Clean(path))
+	if clean == defaultProfilePhoto {
+		return false
+	}
+	for _, dir := range []string{"uploads/photos/", "uploads/profile/"} {
+		if strings.HasPrefix(clean, dir) && !strings.Contains(strings.TrimPrefix(clean, dir), "/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts
+    ADD COLUMN latitude DOUBLE PRECISION
+        CHECK (latitude >= -90 AND latitude <= 90),
+    ADD COLUMN longitude DOUBLE PRECISION
+        CHECK (longitude >= -180 AND longitude <= 180);
+
+-- Backfill from the free-form "lat,lng" string. Rows that do not parse or
+-- fall outside the valid range keep NULL and are simply not searchable.
+-- The casts sit inside CASE so they never run on a malformed row, however
+-- the planner orders the filters; at most three integer digits keeps them in range.
+UPDATE posts p
+SET latitude = parsed.lat,
+    longitude = parsed.lng
+FROM (
+    SELECT id,
+           CASE WHEN regexp_replace(coordinates, '\s', '', 'g') ~ '^-?[0-9]{1,3}(\.[0-9]+)?,-?[0-9]{1,3}(\.[0-9]+)?$'
+                THEN split_part(regexp_replace(coordinates, '\s', '', 'g'), ',', 1)::DOUBLE PRECISION
+           END AS lat,
+           CASE WHEN regexp_replace(coordinates, '\s', '', 'g') ~ '^-?[0-9]{1,3}(\.[0-9]+)?,-?[0-9]{1,3}(\.[0-9]+)?$'
+                THEN split_part(regexp_replace(coordinates, '\s', '', 'g'), ',', 2)::DOUBLE PRECISION
+           END AS lng
+    FROM posts
+) parsed
+WHERE p.id = parsed.id
+  AND parsed.lat BETWEEN -90 AND 90
+  AND parsed.lng BETWEEN -180 AND 180;
+
+CREATE INDEX idx_posts_lat_lng ON posts (latitude, longitude) WHERE latitude IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_lat_lng;
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS longitude;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts
+    ADD COLUMN moderation_status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (moderation_status IN ('pending', 'approved', 'rejected')),
+    ADD COLUMN rejection_reason TEXT,
+    ADD COLUMN moderated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN moderated_at TIMESTAMP;
+
+UPDATE posts SET moderation_status = 'approved' WHERE verified = TRUE;
+
+CREATE INDEX idx_posts_moderation_queue ON posts (created_at) WHERE moderation_status = 'pending' AND draft = FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_moderation_queue;
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS rejection_reason,
+    DROP COLUMN IF EXISTS moderation_status;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE users SET role = 'user' WHERE role IS NULL OR role NOT IN ('user', 'moderator', 'admin');
+
+ALTER TABLE users
+    ALTER COLUMN role SET NOT NULL,
+    ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'moderator', 'admin'));
+
+CREATE TABLE role_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_role VARCHAR(50) NOT NULL,
+    new_role VARCHAR(50) NOT NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_role_changes_user ON role_changes (user_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS role_changes;
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_role_check,
+    ALTER COLUMN role DROP NOT NULL;
+-- +goose StatementEnd
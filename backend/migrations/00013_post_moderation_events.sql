@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- post_id deliberately has no foreign key: the history of a deleted post must survive it.
+-- post_owner_id is kept so the author can still read that history afterwards.
+CREATE TABLE post_moderation_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL,
+    post_owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    event_type VARCHAR(20) NOT NULL
+        CHECK (event_type IN ('created', 'edited', 'approved', 'rejected', 'deleted')),
+    from_status VARCHAR(20),
+    to_status VARCHAR(20),
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_post_moderation_events_post ON post_moderation_events (post_id, created_at);
+
+INSERT INTO post_moderation_events (post_id, post_owner_id, actor_id, event_type, to_status, created_at)
+SELECT id, user_id, user_id, 'created', 'pending', created_at FROM posts;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS post_moderation_events;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- password_resets is keyed by email; let it follow the user when the address changes.
+ALTER TABLE password_resets
+    DROP CONSTRAINT fk_users_email,
+    ADD CONSTRAINT fk_users_email
+        FOREIGN KEY (email)
+        REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE;
+
+-- One pending change per user. After confirmation the row is kept until the
+-- revert window on the old address closes.
+CREATE TABLE email_changes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    code_hash VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP,
+    revert_token_hash CHAR(64) UNIQUE,
+    revert_expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS email_changes;
+ALTER TABLE password_resets
+    DROP CONSTRAINT fk_users_email,
+    ADD CONSTRAINT fk_users_email
+        FOREIGN KEY (email)
+        REFERENCES users(email)
+        ON DELETE CASCADE;
+-- +goose StatementEnd
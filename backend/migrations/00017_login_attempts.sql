@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE password_resets ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+
+-- Keys look like "login:account:<email>" or "login:ip:<ip>" so one table serves every guarded endpoint.
+CREATE TABLE login_attempts (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    lockouts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    last_failure_at TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_attempts;
+ALTER TABLE password_resets DROP COLUMN IF EXISTS attempts;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A scheduled deletion can still be cancelled by logging in before deletion_scheduled_for.
+ALTER TABLE users
+    ADD COLUMN deletion_requested_at TIMESTAMP,
+    ADD COLUMN deletion_scheduled_for TIMESTAMP;
+
+CREATE INDEX idx_users_deletion_scheduled_for ON users(deletion_scheduled_for)
+    WHERE deletion_scheduled_for IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_for;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deletion_requested_at,
+    DROP COLUMN IF EXISTS deletion_scheduled_for;
+-- +goose StatementEnd
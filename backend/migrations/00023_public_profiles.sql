@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN bio TEXT;
+
+-- ISO 3166-1 alpha-2, supplied by the app alongside the coordinates.
+-- Existing posts keep NULL and simply do not count towards countries visited.
+ALTER TABLE posts ADD COLUMN country_code CHAR(2)
+    CHECK (country_code ~ '^[A-Z]{2}$');
+
+CREATE INDEX idx_posts_user_country ON posts(user_id, country_code) WHERE country_code IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_user_country;
+ALTER TABLE posts DROP COLUMN IF EXISTS country_code;
+ALTER TABLE users DROP COLUMN IF EXISTS bio;
+-- +goose StatementEnd
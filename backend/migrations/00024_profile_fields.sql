@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- bio was added with public profiles; gender has existed since 00001 but was never exposed.
+ALTER TABLE users
+    ADD COLUMN home_city VARCHAR(100),
+    ADD COLUMN website VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS home_city,
+    DROP COLUMN IF EXISTS website;
+-- +goose StatementEnd
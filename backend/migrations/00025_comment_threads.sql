@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleted comments stay behind as tombstones (content wiped, deleted_at set) so their replies keep a parent.
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+    ADD COLUMN depth SMALLINT NOT NULL DEFAULT 0 CHECK (depth >= 0),
+    ADD COLUMN edited_at TIMESTAMP,
+    ADD COLUMN deleted_at TIMESTAMP,
+    ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_comments_post_top_level ON comments(post_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_parent ON comments(parent_id, created_at) WHERE parent_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_comments_parent;
+DROP INDEX IF EXISTS idx_comments_post_top_level;
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS edited_at,
+    DROP COLUMN IF EXISTS depth,
+    DROP COLUMN IF EXISTS parent_id;
+-- +goose StatementEnd
@@ -0,0 +1,72 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Usernames are what @mentions refer to. Existing users get one derived from their
+-- name; duplicates get a short suffix taken from their id.
+ALTER TABLE users ADD COLUMN username VARCHAR(30);
+
+WITH base AS (
+    SELECT id, created_at,
+           left(regexp_replace(lower(translate(name || surname, 'çğıöşüÇĞİÖŞÜ', 'cgiosuCGIOSU')), '[^a-z0-9_]', '', 'g'), 20) AS name
+    FROM users
+), candidates AS (
+    SELECT id,
+           CASE WHEN length(name) < 3 THEN 'traveler' || name ELSE name END AS name,
+           row_number() OVER (
+               PARTITION BY CASE WHEN length(name) < 3 THEN 'traveler' || name ELSE name END
+               ORDER BY created_at, id) AS rn
+    FROM base
+)
+UPDATE users u
+SET username = CASE WHEN c.rn = 1 THEN c.name ELSE c.name || '_' || substr(md5(u.id::text), 1, 6) END
+FROM candidates c
+WHERE u.id = c.id;
+
+ALTER TABLE users
+    ALTER COLUMN username SET NOT NULL,
+    ADD CONSTRAINT users_username_key UNIQUE (username),
+    ADD CONSTRAINT chk_users_username CHECK (username ~ '^[a-z0-9_]{3,30}$');
+
+-- Entities are parsed when the text is written and stored with their UTF-16 offsets.
+-- Rows written before this migration start out without any.
+ALTER TABLE posts ADD COLUMN entities JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE comments ADD COLUMN entities JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS post_hashtags (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag VARCHAR(50) NOT NULL,
+    PRIMARY KEY (post_id, tag)
+);
+CREATE INDEX idx_post_hashtags_tag ON post_hashtags(tag);
+
+CREATE TABLE IF NOT EXISTS post_mentions (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, user_id)
+);
+CREATE INDEX idx_post_mentions_user ON post_mentions(user_id);
+
+CREATE TABLE IF NOT EXISTS comment_hashtags (
+    comment_id UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    tag VARCHAR(50) NOT NULL,
+    PRIMARY KEY (comment_id, tag)
+);
+CREATE INDEX idx_comment_hashtags_tag ON comment_hashtags(tag);
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+CREATE INDEX idx_comment_mentions_user ON comment_mentions(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS comment_mentions;
+DROP TABLE IF EXISTS comment_hashtags;
+DROP TABLE IF EXISTS post_mentions;
+DROP TABLE IF EXISTS post_hashtags;
+ALTER TABLE comments DROP COLUMN IF EXISTS entities;
+ALTER TABLE posts DROP COLUMN IF EXISTS entities;
+ALTER TABLE users DROP COLUMN IF EXISTS username;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Emailed codes that confirm an account deletion for users who signed up through a login
+-- provider and never had a password of their own.
+CREATE TABLE account_deletion_codes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_deletion_codes;
+-- +goose StatementEnd
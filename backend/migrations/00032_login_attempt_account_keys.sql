@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Account counters were stored under ":email:" even when the account is a user ID.
+-- Only the label after the scope is renamed; the account itself may contain anything.
+UPDATE login_attempts
+SET key = regexp_replace(key, '^([^:]+):email:', '\1:account:')
+WHERE key ~ '^[^:]+:email:';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE login_attempts
+SET key = regexp_replace(key, '^([^:]+):account:', '\1:email:')
+WHERE key ~ '^[^:]+:account:';
+-- +goose StatementEnd